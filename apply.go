@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/busser/tfautomv/pkg/pretty"
+	"github.com/busser/tfautomv/pkg/terraform"
+)
+
+// applyMoves performs the given moves on the state of each working directory,
+// after asking the user for confirmation unless --auto-approve is set.
+func applyMoves(ctx context.Context, moves []terraform.Move, options []terraform.Option) error {
+	if len(moves) == 0 {
+		os.Stderr.WriteString("no moves to apply\n")
+		return nil
+	}
+
+	if !autoApprove {
+		confirmed, err := confirmMoves(os.Stdin, os.Stderr, moves)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("moves were not applied")
+		}
+	}
+
+	backups, err := terraform.ApplyMoves(ctx, moves, options...)
+	for _, b := range backups {
+		os.Stderr.WriteString(pretty.Colorf("state backed up to [bold]%s", b) + "\n")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply moves, any state already modified was restored from its backup: %w", err)
+	}
+
+	os.Stderr.WriteString(pretty.Colorf("%s applied", pretty.StyledNumMoves(len(moves))) + "\n")
+
+	return nil
+}
+
+// confirmMoves lists the given moves and asks the user to confirm them, the
+// way Terraform does before applying changes.
+func confirmMoves(in io.Reader, out io.Writer, moves []terraform.Move) (bool, error) {
+	var prompt strings.Builder
+
+	prompt.WriteString(pretty.Color("tfautomv will perform the following moves on your state:\n\n"))
+	for _, m := range moves {
+		if m.FromWorkdir == m.ToWorkdir {
+			prompt.WriteString(pretty.Colorf("  [bold]%s[reset] to [bold]%s[reset] in %s\n", m.FromAddress, m.ToAddress, m.FromWorkdir))
+		} else {
+			prompt.WriteString(pretty.Colorf("  [bold]%s[reset] in %s to [bold]%s[reset] in %s\n", m.FromAddress, m.FromWorkdir, m.ToAddress, m.ToWorkdir))
+		}
+	}
+	prompt.WriteString(pretty.Color("\n[bold]Do you want to perform these moves?[reset]\n"))
+	prompt.WriteString("  Only 'yes' will be accepted to approve.\n\n")
+	prompt.WriteString(pretty.Color("  [bold]Enter a value:[reset] "))
+
+	if _, err := io.WriteString(out, prompt.String()); err != nil {
+		return false, err
+	}
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	io.WriteString(out, "\n")
+
+	return strings.TrimSpace(answer) == "yes", nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pmezard/go-difflib/difflib"
+	flag "github.com/spf13/pflag"
+
+	"github.com/busser/tfautomv/pkg/pretty"
+	"github.com/busser/tfautomv/pkg/terraform"
+)
+
+// runClean removes moved blocks that Terraform already applied to the state
+// of each working directory given as argument.
+func runClean(args []string) error {
+	var dryRun, force bool
+
+	flags := flag.NewFlagSet("tfautomv clean", flag.ContinueOnError)
+	flags.BoolVar(&dryRun, "dry-run", false, "print the changes instead of writing them")
+	flags.BoolVar(&force, "force", false, "clean modules that do not appear to manage their own state")
+	flags.BoolVar(&noColor, "no-color", false, "disable color in output")
+	flags.BoolVarP(&skipInit, "skip-init", "s", false, "skip running terraform init")
+	flags.StringVar(&terraformBin, "terraform-bin", "terraform", "terraform binary to use")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := loadSettings(flags); err != nil {
+		return err
+	}
+
+	if noColor {
+		pretty.DisableColors()
+	}
+
+	workdirs := flags.Args()
+	if len(workdirs) == 0 {
+		workdirs = []string{"."}
+	}
+
+	ctx := context.TODO()
+
+	_, isOpenTofu, err := terraform.GetVersion(ctx, terraform.WithTerraformBin(terraformBin))
+	if err != nil {
+		return fmt.Errorf("failed to get Terraform version: %w", err)
+	}
+	openTofu = isOpenTofu
+
+	for _, workdir := range workdirs {
+		if err := cleanWorkdir(ctx, flags, workdir, dryRun, force); err != nil {
+			return fmt.Errorf("failed to clean %q: %w", workdir, err)
+		}
+	}
+
+	return nil
+}
+
+func cleanWorkdir(ctx context.Context, flags *flag.FlagSet, workdir string, dryRun, force bool) error {
+	// Moved blocks in a module others call protect their states, not ours.
+	// Removing them would break callers that have yet to apply the moves.
+	managesState, err := terraform.ManagesState(workdir, configExtensions())
+	if err != nil {
+		return err
+	}
+	if !managesState && !force {
+		return fmt.Errorf("no backend or local state found, this may be a module published for others to use; use --force to clean it anyway")
+	}
+
+	state, err := terraform.GetState(ctx, append(
+		[]terraform.Option{
+			terraform.WithWorkdir(workdir),
+			terraform.WithTerraformBin(terraformBin),
+			terraform.WithSkipInit(skipInit),
+		},
+		workdirOptions(flags, workdir)...,
+	)...)
+	if err != nil {
+		return err
+	}
+
+	addresses := terraform.StateAddresses(state)
+	if len(addresses) == 0 && !force {
+		return fmt.Errorf("state is empty, refusing to clean moved blocks that were never applied; use --force to clean it anyway")
+	}
+
+	files, err := terraform.CleanMovedBlocks(workdir, configExtensions(), addresses)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		os.Stderr.WriteString(pretty.Colorf("no moved blocks to clean in [bold][green]%s", workdir) + "\n")
+		return nil
+	}
+
+	removed := 0
+	for _, f := range files {
+		removed += len(f.Removed)
+
+		if dryRun {
+			diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        difflib.SplitLines(string(f.Before)),
+				B:        difflib.SplitLines(string(f.After)),
+				FromFile: f.Path,
+				ToFile:   f.Path,
+				Context:  3,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to compute diff of %q: %w", f.Path, err)
+			}
+			fmt.Print(diff)
+			continue
+		}
+
+		// A file left with nothing in it is better removed than kept empty.
+		if len(f.After) == 0 {
+			err = os.Remove(f.Path)
+		} else {
+			err = os.WriteFile(f.Path, f.After, 0644)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %q: %w", f.Path, err)
+		}
+	}
+
+	if dryRun {
+		os.Stderr.WriteString(pretty.Colorf("%s would be removed from [bold][green]%s", pretty.StyledNumMoves(removed), workdir) + "\n")
+	} else {
+		os.Stderr.WriteString(pretty.Colorf("%s removed from [bold][green]%s", pretty.StyledNumMoves(removed), workdir) + "\n")
+	}
+
+	return nil
+}
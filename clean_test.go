@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/busser/tfautomv/pkg/config"
+)
+
+func TestRunCleanWithConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		args     []string
+		wantInit bool
+	}{
+		{
+			name:     "without configuration",
+			wantInit: true,
+		},
+		{
+			name:     "workdir skips init",
+			config:   `workdir "prod" { skip_init = true }`,
+			wantInit: false,
+		},
+		{
+			name:     "flag overrides workdir",
+			config:   `workdir "prod" { skip_init = true }`,
+			args:     []string{"--skip-init=false"},
+			wantInit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			if err := os.Mkdir(filepath.Join(root, "prod"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.config != "" {
+				if err := os.WriteFile(filepath.Join(root, config.FileName), []byte(tt.config), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// The fake binary logs the commands it runs, and reports an empty
+			// state.
+			log := filepath.Join(root, "commands.log")
+			bin := filepath.Join(root, "terraform")
+			script := `#!/bin/sh
+echo "$1" >> ` + log + `
+case "$1" in
+version)
+	if [ "$2" = "-json" ]; then
+		echo '{"terraform_version": "1.9.5", "platform": "linux_amd64", "provider_selections": {}, "terraform_outdated": false}'
+	else
+		echo 'Terraform v1.9.5'
+	fi
+	;;
+show)
+	echo '{"format_version": "1.0"}'
+	;;
+esac
+`
+			if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			chdir(t, root)
+			t.Cleanup(func() { projectConfig = nil })
+
+			args := append([]string{"--force", "--terraform-bin", bin}, tt.args...)
+			if err := runClean(append(args, "prod")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			raw, err := os.ReadFile(log)
+			if err != nil {
+				t.Fatal(err)
+			}
+			commands := strings.Fields(string(raw))
+
+			gotInit := false
+			for _, c := range commands {
+				gotInit = gotInit || c == "init"
+			}
+			if gotInit != tt.wantInit {
+				t.Errorf("ran init = %v, want %v, commands: %v", gotInit, tt.wantInit, commands)
+			}
+		})
+	}
+}
+
+// chdir changes the working directory for the duration of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(previous); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
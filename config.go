@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/busser/tfautomv/pkg/config"
+	"github.com/busser/tfautomv/pkg/terraform"
+)
+
+// Settings from the project's configuration file. Nil if there is none.
+var projectConfig *config.Config
+
+// loadSettings fills in the flags the user did not set on the command line,
+// first from TFAUTOMV_* environment variables, then from the configuration
+// file found in the current directory or one of its parents. Rules passed with
+// --ignore or --type-equivalence are combined from all sources instead.
+func loadSettings(flags *flag.FlagSet) error {
+	if err := applyEnvironment(flags); err != nil {
+		return err
+	}
+
+	path, err := config.Find(".")
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return nil
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	projectConfig = cfg
+
+	return applyConfig(flags, cfg, path)
+}
+
+// envName returns the environment variable that sets the given flag, like
+// TFAUTOMV_SKIP_INIT for --skip-init.
+func envName(flagName string) string {
+	return "TFAUTOMV_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnvironment sets flags from environment variables, as if they were
+// passed on the command line. Flags the user passed on the command line are
+// left as they are, except lists, which the environment adds to.
+func applyEnvironment(flags *flag.FlagSet) error {
+	var errs []error
+	flags.VisitAll(func(f *flag.Flag) {
+		if f.Name == "version" {
+			return
+		}
+		_, isList := f.Value.(flag.SliceValue)
+		if f.Changed && !isList {
+			return
+		}
+
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+
+		// Values of lists can contain commas, so they are separated by
+		// newlines instead.
+		values := []string{value}
+		if isList {
+			values = strings.Split(value, "\n")
+		}
+		for _, v := range values {
+			if err := flags.Set(f.Name, v); err != nil {
+				errs = append(errs, fmt.Errorf("invalid value %q for %s: %w", v, envName(f.Name), err))
+			}
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// applyConfig sets the flags the user did not set from the configuration
+// file. Unlike the command line and the environment, the file does not mark
+// flags as changed, since those are explicit choices of the user.
+func applyConfig(flags *flag.FlagSet, cfg *config.Config, path string) error {
+	for _, err := range []error{
+		appendFromConfig(flags, "ignore", cfg.Ignore),
+		appendFromConfig(flags, "type-equivalence", cfg.TypeEquivalences),
+		setFromConfig(flags, "assignment", cfg.Assignment),
+		setFromConfig(flags, "ignore-empty", cfg.IgnoreEmpty),
+		setFromConfig(flags, "min-similarity", cfg.MinSimilarity),
+		setFromConfig(flags, "moves-file", cfg.MovesFile),
+		setFromConfig(flags, "output", cfg.Output),
+		setFromConfig(flags, "preplanned", cfg.Preplanned),
+		setFromConfig(flags, "preplanned-file", cfg.PreplannedFile),
+		setFromConfig(flags, "skip-init", cfg.SkipInit),
+		setFromConfig(flags, "skip-refresh", cfg.SkipRefresh),
+		setFromConfig(flags, "strict", cfg.Strict),
+		setFromConfig(flags, "terraform-bin", cfg.TerraformBin),
+		setFromConfig(flags, "unordered-sets", cfg.UnorderedSets),
+	} {
+		if err != nil {
+			return fmt.Errorf("invalid configuration in %q: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+func setFromConfig[T any](flags *flag.FlagSet, name string, value *T) error {
+	f := flags.Lookup(name)
+	if f == nil || f.Changed || value == nil {
+		return nil
+	}
+
+	if err := f.Value.Set(fmt.Sprint(*value)); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", strings.ReplaceAll(name, "-", "_"), err)
+	}
+
+	return nil
+}
+
+func appendFromConfig(flags *flag.FlagSet, name string, values []string) error {
+	f := flags.Lookup(name)
+	if f == nil {
+		return nil
+	}
+
+	list := f.Value.(flag.SliceValue)
+	for _, v := range values {
+		if err := list.Append(v); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", strings.ReplaceAll(name, "-", "_"), err)
+		}
+	}
+
+	return nil
+}
+
+// workdirOptions returns the Terraform options the configuration file sets
+// for the given working directory. They override the options of the top-level
+// settings, but not those the user passed as flags or environment variables
+// in the given set.
+func workdirOptions(flags *flag.FlagSet, workdir string) []terraform.Option {
+	if projectConfig == nil {
+		return nil
+	}
+
+	w, ok := projectConfig.Workdir(workdir)
+	if !ok {
+		return nil
+	}
+
+	var options []terraform.Option
+	if w.SkipInit != nil && !changed(flags, "skip-init") {
+		options = append(options, terraform.WithSkipInit(*w.SkipInit))
+	}
+	if w.SkipRefresh != nil && !changed(flags, "skip-refresh") {
+		options = append(options, terraform.WithSkipRefresh(*w.SkipRefresh))
+	}
+
+	return options
+}
+
+// workdirPlanFile returns the name of the plan file to read in the given
+// working directory with --preplanned.
+func workdirPlanFile(flags *flag.FlagSet, workdir, planFilename string) string {
+	if projectConfig == nil || changed(flags, "preplanned-file") {
+		return planFilename
+	}
+
+	w, ok := projectConfig.Workdir(workdir)
+	if !ok || w.PreplannedFile == nil {
+		return planFilename
+	}
+
+	return *w.PreplannedFile
+}
+
+// changed returns whether the user set the given flag, on the command line or
+// with an environment variable. Subcommands do not define every flag.
+func changed(flags *flag.FlagSet, name string) bool {
+	f := flags.Lookup(name)
+	return f != nil && f.Changed
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/busser/tfautomv/pkg/engine"
+	"github.com/busser/tfautomv/pkg/pretty"
+)
+
+// explainResource prints how the engine compared the resource at the given
+// address to every candidate, instead of the usual summary. The address can
+// be prefixed with the resource's working directory and a colon, for when the
+// same address appears in several working directories.
+func explainResource(address string, plan engine.Plan, moves []engine.Move, comparisons []engine.ResourceComparison, userRules []engine.Rule) error {
+	summarizer := pretty.NewSummarizer(moves, comparisons, verbosity)
+
+	var explanations []string
+	for _, r := range plan.ToCreate {
+		if r.Address == address || r.ID() == address {
+			explanations = append(explanations, summarizer.ExplainResourceToCreate(r, userRules))
+		}
+	}
+	for _, r := range plan.ToDelete {
+		if r.Address == address || r.ID() == address {
+			explanations = append(explanations, summarizer.ExplainResourceToDelete(r, userRules))
+		}
+	}
+
+	if len(explanations) == 0 {
+		return fmt.Errorf("Terraform does not plan to create or delete %s", address)
+	}
+
+	for _, exp := range explanations {
+		os.Stderr.WriteString("\n" + exp + "\n\n")
+	}
+
+	return nil
+}
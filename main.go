@@ -5,8 +5,11 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/hashicorp/go-version"
@@ -15,6 +18,7 @@ import (
 	"github.com/busser/tfautomv/pkg/engine"
 	"github.com/busser/tfautomv/pkg/engine/rules"
 	"github.com/busser/tfautomv/pkg/pretty"
+	"github.com/busser/tfautomv/pkg/report"
 	"github.com/busser/tfautomv/pkg/terraform"
 )
 
@@ -29,9 +33,21 @@ func main() {
 var tfautomvVersion string
 
 func run() error {
+	if len(os.Args) > 1 && os.Args[1] == "clean" {
+		return runClean(os.Args[2:])
+	}
+
 	parseFlags()
 
-	workdirs := flag.Args()
+	if err := loadSettings(flag.CommandLine); err != nil {
+		return err
+	}
+
+	explain, explainAddress, workdirs, err := parseArgs(flag.Args())
+	if err != nil {
+		return err
+	}
+
 	if len(workdirs) == 0 {
 		workdirs = []string{"."}
 	}
@@ -53,32 +69,95 @@ func run() error {
 	 * Detect any obvious issues with the user's configuration.
 	 */
 
-	if outputFormat != "auto" && outputFormat != "blocks" && outputFormat != "commands" {
+	if !slices.Contains([]string{"auto", "blocks", "commands", "imports", "json"}, outputFormat) {
 		return fmt.Errorf("unknown output format %q", outputFormat)
 	}
 
-	if outputFormat == "blocks" && len(flag.Args()) > 1 {
+	if outputFormat == "blocks" && len(workdirs) > 1 {
 		return fmt.Errorf("blocks output format is not supported for multiple modules")
 	}
 
+	if !slices.Contains(engine.Assignments, engine.Assignment(assignment)) {
+		return fmt.Errorf("unknown assignment strategy %q", assignment)
+	}
+
+	if minSimilarity < 0 || minSimilarity > 1 {
+		return fmt.Errorf("--min-similarity must be between 0 and 1, got %v", minSimilarity)
+	}
+
+	if apply && flag.Lookup("output").Changed {
+		return fmt.Errorf("--apply cannot be used with --output, since it performs the moves instead of writing them")
+	}
+
+	if suggestRules && apply {
+		return fmt.Errorf("--apply cannot be used with --suggest-rules, since it only suggests rules instead of writing moves")
+	}
+
+	if explain && apply {
+		return fmt.Errorf("--apply cannot be used with explain, since it only explains how a resource was compared")
+	}
+
+	if autoApprove && !apply {
+		return fmt.Errorf("--auto-approve can only be used with --apply")
+	}
+
 	if usePreplanned && (skipInit || skipRefresh) {
 		return fmt.Errorf("--preplanned cannot be used with --skip-init or --skip-refresh flags")
 	}
 
+	if iterate && usePreplanned {
+		return fmt.Errorf("--iterate cannot be used with --preplanned, since it needs to run terraform plan")
+	}
+
 	if !usePreplanned && flag.Lookup("preplanned-file").Changed {
 		return fmt.Errorf("--preplanned-file can only be used with --preplanned")
 	}
 
-	tfVersion, err := terraform.GetVersion(ctx, terraform.WithTerraformBin(terraformBin))
+	tfVersion, isOpenTofu, err := terraform.GetVersion(ctx, terraform.WithTerraformBin(terraformBin))
 	if err != nil {
 		return fmt.Errorf("failed to get Terraform version: %w", err)
 	}
+	openTofu = isOpenTofu
+
+	if movesFile == "" {
+		movesFile = defaultMovesFile()
+	}
+
+	if !slices.Contains(nativeExtensions(), filepath.Ext(movesFile)) || filepath.Base(movesFile) != movesFile {
+		return fmt.Errorf("--moves-file must be a file name ending with %s, got %q", strings.Join(nativeExtensions(), " or "), movesFile)
+	}
 
 	movedBlocksSupported := tfVersion.GreaterThanOrEqual(version.Must(version.NewSemver("1.1.0")))
 	if outputFormat == "blocks" && !movedBlocksSupported {
 		return fmt.Errorf("Terraform version %s does not support moved blocks", tfVersion)
 	}
 
+	if verify && !movedBlocksSupported {
+		return fmt.Errorf("Terraform version %s does not support moved blocks, which --verify requires", tfVersion)
+	}
+
+	if iterate && !movedBlocksSupported {
+		return fmt.Errorf("Terraform version %s does not support moved blocks, which --iterate requires", tfVersion)
+	}
+
+	removedBlocksSupported := tfVersion.GreaterThanOrEqual(version.Must(version.NewSemver("1.7.0")))
+	if outputFormat == "imports" && !removedBlocksSupported {
+		return fmt.Errorf("Terraform version %s does not support removed blocks, which the imports output format requires", tfVersion)
+	}
+
+	crossTypeMovesSupported := tfVersion.GreaterThanOrEqual(version.Must(version.NewSemver("1.8.0")))
+	if len(typeEquivalences) > 0 && !crossTypeMovesSupported {
+		return fmt.Errorf("Terraform version %s does not support moves across resource types, which --type-equivalence requires", tfVersion)
+	}
+
+	if len(typeEquivalences) > 0 && outputFormat == "commands" {
+		return fmt.Errorf("--type-equivalence cannot be used with commands output format, since only moved blocks can move resources across types")
+	}
+
+	if len(typeEquivalences) > 0 && apply {
+		return fmt.Errorf("--type-equivalence cannot be used with --apply, since only moved blocks can move resources across types")
+	}
+
 	crossModuleMovesSupported := tfVersion.GreaterThanOrEqual(version.Must(version.NewSemver("0.14.0")))
 	if len(workdirs) > 1 && !crossModuleMovesSupported {
 		return fmt.Errorf("Terraform version %s does not support moves across modules", tfVersion)
@@ -93,12 +172,32 @@ func run() error {
 
 	var userRules []engine.Rule
 	for _, raw := range ignoreRules {
-		rule, err := rules.Parse(raw)
+		parsed, err := parseListValue(raw, rules.Parse)
 		if err != nil {
 			return fmt.Errorf("invalid rule passed with -ignore flag %q: %w", raw, err)
 		}
 
-		userRules = append(userRules, rule)
+		userRules = append(userRules, parsed...)
+	}
+	if ignoreEmpty {
+		userRules = append(userRules, rules.Empty("*", "**"))
+	}
+
+	// Terraform can only move resources across types with moved blocks, so
+	// built-in equivalences are only used when moved blocks will be written.
+	// With --apply, moves are performed with terraform state mv instead.
+	compareOptions := []engine.Option{engine.WithStrict(strict)}
+	if crossTypeMovesSupported && outputFormat != "commands" && !apply {
+		compareOptions = append(compareOptions, engine.WithTypeEquivalences(engine.DefaultTypeEquivalences))
+	}
+
+	for _, raw := range typeEquivalences {
+		parsed, err := parseListValue(raw, engine.ParseTypeEquivalence)
+		if err != nil {
+			return fmt.Errorf("invalid equivalence passed with --type-equivalence flag %q: %w", raw, err)
+		}
+
+		compareOptions = append(compareOptions, engine.WithTypeEquivalences(parsed))
 	}
 
 	/*
@@ -119,12 +218,22 @@ func run() error {
 	if usePreplanned {
 		plans, err = getPreplannedPlans(ctx, workdirs, preplannedFile, terraformOptions)
 	} else {
-		plans, err = getPlans(ctx, workdirs, terraformOptions)
+		plans, err = getPlans(ctx, workdirs, workdirs, terraformOptions)
 	}
 	if err != nil {
 		return err
 	}
 
+	// Terraform may return the elements of a set in any order, so they are
+	// compared regardless of order.
+	if unorderedSets {
+		setRules, err := unorderedSetRules(ctx, workdirs, terraformOptions)
+		if err != nil {
+			return err
+		}
+		userRules = append(userRules, setRules...)
+	}
+
 	/*
 	 * Step 3: Use the tfautomv engine to determine moves to make
 	 *
@@ -134,8 +243,26 @@ func run() error {
 	 */
 
 	mergedPlan := engine.MergePlans(plans)
-	comparisons := engine.CompareAll(mergedPlan, userRules)
-	moves := engine.DetermineMoves(comparisons)
+	comparisons := engine.CompareAll(mergedPlan, userRules, compareOptions...)
+	moves := engine.DetermineMoves(comparisons, engineOptions(mergedPlan)...)
+
+	/*
+	 * Step 3b: Look for moves revealed by the moves we found
+	 *
+	 * Some resources only differ because something they depend on is being
+	 * recreated. Once the moves we found are applied, they may match too.
+	 */
+
+	if iterate {
+		moves, comparisons, err = iterateMoves(ctx, workdirs, moves, comparisons, userRules, compareOptions, terraformOptions)
+		if err != nil {
+			return err
+		}
+	}
+
+	if explain {
+		return explainResource(explainAddress, mergedPlan, moves, comparisons, userRules)
+	}
 
 	/*
 	 * Step 4: Print a human-readable summary for the user
@@ -150,6 +277,29 @@ func run() error {
 
 	os.Stderr.WriteString("\n" + summary + "\n\n")
 
+	// When a module call is renamed, a single move of the module instance
+	// replaces the moves of every resource within it.
+	collapsedMoves := engine.CollapseModuleMoves(moves, mergedPlan)
+
+	if reportFile != "" {
+		if err := writeReportFile(reportFile, mergedPlan, moves, collapsedMoves, comparisons); err != nil {
+			return err
+		}
+	}
+
+	if htmlReport != "" {
+		if err := writeHTMLReport(htmlReport, mergedPlan, moves, comparisons); err != nil {
+			return err
+		}
+	}
+
+	if suggestRules {
+		options := append(append([]engine.Option(nil), compareOptions...), engineOptions(mergedPlan)...)
+		suggestions := rules.Suggestions(mergedPlan, userRules, maxSuggestedMismatches, options...)
+		os.Stderr.WriteString(pretty.RuleSuggestions(suggestions) + "\n\n")
+		return nil
+	}
+
 	/*
 	 * Step 5: Write the moves found by the engine.
 	 *
@@ -158,17 +308,45 @@ func run() error {
 	 * of both.
 	 */
 
-	terraformMoves := engineMovesToTerraformMoves(moves)
+	if len(collapsedMoves) < len(moves) {
+		os.Stderr.WriteString(pretty.Colorf("collapsed %s into %s of whole modules or resources", pretty.StyledNumMoves(len(moves)), pretty.StyledNumMoves(len(collapsedMoves))) + "\n")
+	}
+
+	if verify {
+		if err := verifyMoves(ctx, workdirs, collapsedMoves, terraformOptions); err != nil {
+			return err
+		}
+	}
+
+	terraformMoves := engineMovesToTerraformMoves(collapsedMoves)
+
+	if markdownReport != "" {
+		if err := writeMarkdownReport(markdownReport, &summarizer, terraformMoves, userRules); err != nil {
+			return err
+		}
+	}
+
+	if apply {
+		return applyMoves(ctx, terraformMoves, terraformOptions)
+	}
 	sameModule, differentModule := categorizeMoves(terraformMoves)
 
+	// Moved blocks cannot express moves that form a chain, like `a` to `b` and
+	// `b` to `c`, or a cycle, like two resources swapping addresses. Only state
+	// mv commands, run in order, can perform those moves.
+	independent, chained := terraform.SplitChains(sameModule)
+	if len(chained) > 0 && (outputFormat == "blocks" || outputFormat == "imports") {
+		return fmt.Errorf("%d moves form a chain or a cycle, such as %s to %s, which moved blocks cannot express; use --output=commands or --output=auto instead", len(chained), chained[0].FromAddress, chained[0].ToAddress)
+	}
+
 	switch outputFormat {
 	case "auto":
 		switch {
 		case movedBlocksSupported:
-			if err := writeMovedBlocks(sameModule); err != nil {
+			if err := writeMovedBlocks(independent); err != nil {
 				return err
 			}
-			if err := writeMoveCommands(differentModule, terraformOptions...); err != nil {
+			if err := writeMoveCommands(append(chained, differentModule...), terraformOptions...); err != nil {
 				return err
 			}
 		case !movedBlocksSupported:
@@ -184,6 +362,17 @@ func run() error {
 		if err := writeMoveCommands(terraformMoves, terraformOptions...); err != nil {
 			return err
 		}
+	case "imports":
+		if err := writeMovedBlocks(sameModule); err != nil {
+			return err
+		}
+		if err := writeRemovedAndImportBlocks(withImportIDs(differentModule, comparisons), mergedPlan); err != nil {
+			return err
+		}
+	case "json":
+		if err := report.New(mergedPlan, moves, collapsedMoves, comparisons, strings.TrimSpace(tfautomvVersion)).Write(os.Stdout); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
 	default:
 		// This should have been caught by the smoke tests.
 		return fmt.Errorf("unknown output format %q", outputFormat)
@@ -194,33 +383,112 @@ func run() error {
 
 // Flags
 var (
-	ignoreRules    []string
-	noColor        bool
-	outputFormat   string
-	printVersion   bool
-	skipInit       bool
-	skipRefresh    bool
-	terraformBin   string
-	verbosity      int
-	preplannedFile string
-	usePreplanned  bool
+	apply            bool
+	assignment       string
+	autoApprove      bool
+	htmlReport       string
+	ignoreEmpty      bool
+	ignoreRules      []string
+	iterate          bool
+	keyHeuristics    bool
+	markdownReport   string
+	minSimilarity    float64
+	movesFile        string
+	noColor          bool
+	outputFormat     string
+	printVersion     bool
+	reportFile       string
+	skipInit         bool
+	skipRefresh      bool
+	strict           bool
+	suggestRules     bool
+	terraformBin     string
+	typeEquivalences []string
+	unorderedSets    bool
+	verbosity        int
+	verify           bool
+	preplannedFile   string
+	usePreplanned    bool
 )
 
 func parseFlags() {
-	flag.StringSliceVar(&ignoreRules, "ignore", nil, "ignore differences based on a `rule`")
-	flag.BoolVar(&noColor, "no-color", false, "disable color in output")
-	flag.StringVarP(&outputFormat, "output", "o", "auto", "output `format` of moves (\"auto\", \"blocks\" or \"commands\")")
-	flag.BoolVarP(&printVersion, "version", "V", false, "print version and exit")
-	flag.BoolVarP(&skipInit, "skip-init", "s", false, "skip running terraform init")
-	flag.BoolVarP(&skipRefresh, "skip-refresh", "S", false, "skip running terraform refresh")
-	flag.StringVar(&terraformBin, "terraform-bin", "terraform", "terraform binary to use")
-	flag.CountVarP(&verbosity, "verbosity", "v", "increase verbosity (can be specified multiple times)")
-	flag.BoolVar(&usePreplanned, "preplanned", false, "use existing plan files instead of running terraform plan")
-	flag.StringVar(&preplannedFile, "preplanned-file", "tfplan.bin", "plan file name when using --preplanned")
-
+	registerFlags(flag.CommandLine)
 	flag.Parse()
 }
 
+// registerFlags defines the command-line flags in the given set.
+func registerFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&apply, "apply", false, "perform the moves on the state directly instead of writing them")
+	flags.StringVar(&assignment, "assignment", "exclusive", "`strategy` for resources with multiple matches (\"exclusive\", \"optimal\" or \"best-effort\")")
+	flags.BoolVar(&autoApprove, "auto-approve", false, "skip confirmation before performing moves with --apply")
+	flags.StringVar(&htmlReport, "html-report", "", "write an HTML page to explore the comparisons to this `path`")
+	flags.StringArrayVar(&ignoreRules, "ignore", nil, "ignore differences based on a `rule` (can be specified multiple times)")
+	flags.BoolVar(&ignoreEmpty, "ignore-empty", false, "treat null values, empty strings and empty lists as equal in all resources")
+	flags.BoolVar(&iterate, "iterate", false, "plan again with the moves found until no new moves appear")
+	flags.BoolVar(&keyHeuristics, "key-heuristics", false, "pair instances converted from count to for_each based on attributes that give away their keys")
+	flags.StringVar(&markdownReport, "markdown-report", "", "write a Markdown report of the findings, for pull request comments, to this `path`")
+	flags.Float64Var(&minSimilarity, "min-similarity", 0, "move resources without a match if their similarity is at least this `ratio` (between 0 and 1)")
+	flags.StringVar(&movesFile, "moves-file", "", "`name` of the file moved blocks are written to, in each working directory (default \"moves.tf\", or \"moves.tofu\" with OpenTofu)")
+	flags.BoolVar(&noColor, "no-color", false, "disable color in output")
+	flags.StringVarP(&outputFormat, "output", "o", "auto", "output `format` of moves (\"auto\", \"blocks\", \"commands\", \"imports\" or \"json\")")
+	flags.StringVar(&reportFile, "report-file", "", "write a JSON report of the moves and comparisons to this `path`")
+	flags.BoolVarP(&printVersion, "version", "V", false, "print version and exit")
+	flags.BoolVarP(&skipInit, "skip-init", "s", false, "skip running terraform init")
+	flags.BoolVarP(&skipRefresh, "skip-refresh", "S", false, "skip running terraform refresh")
+	flags.BoolVar(&strict, "strict", false, "also compare attributes only the resources to delete have, so that resources do not match if the new one lost attributes")
+	flags.BoolVar(&suggestRules, "suggest-rules", false, "suggest rules to ignore differences with, instead of writing moves")
+	flags.StringVar(&terraformBin, "terraform-bin", "terraform", "terraform binary to use")
+	flags.StringArrayVar(&typeEquivalences, "type-equivalence", nil, "allow moves across resource types based on an `equivalence` (FROM:TO[:ATTR=ATTR,...], can be specified multiple times)")
+	flags.BoolVar(&unorderedSets, "unordered-sets", false, "ignore the order of elements in attributes the provider schemas define as sets")
+	flags.CountVarP(&verbosity, "verbosity", "v", "increase verbosity (can be specified multiple times)")
+	flags.BoolVar(&verify, "verify", false, "plan again with the moves found and check that no resource is still replaced")
+	flags.BoolVar(&usePreplanned, "preplanned", false, "use existing plan files instead of running terraform plan")
+	flags.StringVar(&preplannedFile, "preplanned-file", "tfplan.bin", "plan file name when using --preplanned")
+}
+
+// parseListValue parses a value of --ignore or --type-equivalence. Earlier
+// versions of tfautomv split these values on commas, which rules and
+// equivalences can contain. A value that is not valid as a whole is still
+// split on commas, so that "--ignore=a,b" keeps working.
+func parseListValue[T any](raw string, parse func(string) (T, error)) ([]T, error) {
+	v, err := parse(raw)
+	if err == nil {
+		return []T{v}, nil
+	}
+	if !strings.Contains(raw, ",") {
+		return nil, err
+	}
+
+	var values []T
+	for _, part := range strings.Split(raw, ",") {
+		v, splitErr := parse(part)
+		if splitErr != nil {
+			// The error of the whole value is the most helpful, since the
+			// user most likely meant a single value.
+			return nil, err
+		}
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+// parseArgs splits the arguments left after parsing flags into the explain
+// subcommand, the address it explains, and working directories. The explain
+// subcommand shares every flag with the main command, so flags may come
+// before it as well as after it.
+func parseArgs(args []string) (explain bool, explainAddress string, workdirs []string, err error) {
+	if len(args) == 0 || args[0] != "explain" {
+		return false, "", args, nil
+	}
+
+	if len(args) == 1 {
+		return false, "", nil, fmt.Errorf("explain requires the address of a resource Terraform plans to create or delete")
+	}
+
+	return true, args[1], args[2:], nil
+}
+
 func engineMovesToTerraformMoves(moves []engine.Move) []terraform.Move {
 	var terraformMoves []terraform.Move
 
@@ -236,7 +504,158 @@ func engineMovesToTerraformMoves(moves []engine.Move) []terraform.Move {
 	return terraformMoves
 }
 
-func getPlans(ctx context.Context, workdirs []string, options []terraform.Option) ([]engine.Plan, error) {
+func engineOptions(plan engine.Plan) []engine.Option {
+	return []engine.Option{
+		engine.WithAssignment(engine.Assignment(assignment)),
+		engine.WithMinSimilarity(minSimilarity),
+		engine.WithDependencies(plan.Dependencies),
+		engine.WithKeyHeuristics(keyHeuristics),
+	}
+}
+
+// maxSuggestedMismatches is how many attributes two resources can disagree on
+// for --suggest-rules to suggest rules that would make them match. Beyond
+// that, the resources are unlikely to be the same.
+const maxSuggestedMismatches = 3
+
+// maxRounds limits how many times --iterate plans again, in case each round
+// keeps revealing new moves.
+const maxRounds = 10
+
+// iterateMoves writes the moves found so far to scratch copies of each working
+// directory, plans again, and looks for new moves. It repeats this until no
+// new moves appear. It returns the moves found in all rounds, along with the
+// comparisons of the last round and those that explain earlier moves.
+func iterateMoves(ctx context.Context, workdirs []string, moves []engine.Move, comparisons []engine.ResourceComparison, userRules []engine.Rule, compareOptions []engine.Option, options []terraform.Option) ([]engine.Move, []engine.ResourceComparison, error) {
+	// Comparisons that explain moves found in previous rounds. Resources that
+	// were moved do not appear in later plans.
+	explanations := explainMoves(moves, comparisons)
+
+	rounds := 1
+	fixpoint := false
+	for !fixpoint && rounds < maxRounds {
+		scratchDirs, removeScratch, err := scratchWorkdirs(workdirs, moves)
+		if err != nil {
+			removeScratch()
+			return nil, nil, err
+		}
+
+		os.Stderr.WriteString(pretty.Colorf("planning again with %s (round %d)...", pretty.StyledNumMoves(len(moves)), rounds+1) + "\n")
+
+		plans, err := getPlans(ctx, workdirs, scratchDirs, options)
+		removeScratch()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mergedPlan := engine.MergePlans(plans)
+		comparisons = engine.CompareAll(mergedPlan, userRules, compareOptions...)
+		roundMoves := engine.DetermineMoves(comparisons, engineOptions(mergedPlan)...)
+
+		// Moves between working directories cannot be written as moved
+		// blocks, so they are found again in every round.
+		var newMoves []engine.Move
+		for _, m := range roundMoves {
+			if !slices.Contains(moves, m) {
+				newMoves = append(newMoves, m)
+			}
+		}
+
+		rounds++
+
+		if len(newMoves) == 0 {
+			fixpoint = true
+			break
+		}
+
+		moves = append(moves, newMoves...)
+		explanations = append(explanations, explainMoves(newMoves, comparisons)...)
+	}
+
+	if fixpoint {
+		os.Stderr.WriteString(pretty.Colorf("no new moves appeared after [bold]%d rounds", rounds) + "\n")
+	} else {
+		os.Stderr.WriteString(pretty.Colorf("[yellow][bold]stopped after %d rounds[reset], more moves may be possible", rounds) + "\n")
+	}
+
+	for _, e := range explanations {
+		if !slices.ContainsFunc(comparisons, func(c engine.ResourceComparison) bool {
+			return c.ToCreate.ID() == e.ToCreate.ID() && c.ToDelete.ID() == e.ToDelete.ID()
+		}) {
+			comparisons = append(comparisons, e)
+		}
+	}
+
+	return moves, comparisons, nil
+}
+
+// explainMoves returns the comparison each move is based on.
+func explainMoves(moves []engine.Move, comparisons []engine.ResourceComparison) []engine.ResourceComparison {
+	var explanations []engine.ResourceComparison
+	for _, m := range moves {
+		for _, c := range comparisons {
+			if c.ToDelete.ModuleID == m.SourceModule && c.ToDelete.Address == m.SourceAddress &&
+				c.ToCreate.ModuleID == m.DestinationModule && c.ToCreate.Address == m.DestinationAddress {
+				explanations = append(explanations, c)
+				break
+			}
+		}
+	}
+	return explanations
+}
+
+// scratchWorkdirs copies each working directory to a scratch directory and
+// writes moved blocks for the given moves within that directory. It returns
+// the scratch directories created, and a function that removes them, even if
+// an error occurs.
+func scratchWorkdirs(workdirs []string, moves []engine.Move) ([]string, func(), error) {
+	sameWorkdir, _ := categorizeMoves(engineMovesToTerraformMoves(moves))
+
+	// Moves that form a chain cannot be written as moved blocks. They are
+	// found again in every round, like moves between working directories.
+	sameWorkdir, _ = terraform.SplitChains(sameWorkdir)
+
+	movesByWorkdir := make(map[string][]terraform.Move)
+	for _, m := range sameWorkdir {
+		movesByWorkdir[m.FromWorkdir] = append(movesByWorkdir[m.FromWorkdir], m)
+	}
+
+	var scratchDirs []string
+	var removes []func()
+	removeAll := func() {
+		for _, remove := range removes {
+			remove()
+		}
+	}
+
+	for _, workdir := range workdirs {
+		scratch, remove, err := terraform.CopyWorkdir(workdir)
+		if err != nil {
+			return scratchDirs, removeAll, err
+		}
+		scratchDirs = append(scratchDirs, scratch)
+		removes = append(removes, remove)
+
+		movesFilePath := filepath.Join(scratch, "tfautomv_iterate.tf")
+		movesFile, err := os.Create(movesFilePath)
+		if err != nil {
+			return scratchDirs, removeAll, fmt.Errorf("failed to create %q: %w", movesFilePath, err)
+		}
+
+		err = terraform.WriteMovedBlocks(movesFile, movesByWorkdir[workdir])
+		movesFile.Close()
+		if err != nil {
+			return scratchDirs, removeAll, fmt.Errorf("failed to write moved blocks: %w", err)
+		}
+	}
+
+	return scratchDirs, removeAll, nil
+}
+
+// getPlans obtains a plan for each working directory. Terraform runs in the
+// corresponding directory of rundirs, which is usually the working directory
+// itself.
+func getPlans(ctx context.Context, workdirs, rundirs []string, options []terraform.Option) ([]engine.Plan, error) {
 	type result struct {
 		plan engine.Plan
 		err  error
@@ -249,10 +668,16 @@ func getPlans(ctx context.Context, workdirs []string, options []terraform.Option
 		os.Stderr.WriteString(pretty.Colorf("getting Terraform plan for %s...", (*pretty.Summarizer).StyledModule(nil, workdir)) + "\n")
 
 		workdirOptions := append(
-			[]terraform.Option{terraform.WithWorkdir(workdir)},
-			options...,
+			append([]terraform.Option{terraform.WithWorkdir(rundirs[i])}, options...),
+			workdirOptions(flag.CommandLine, workdir)...,
 		)
 
+		// Scratch copies share the original's providers, which terraform
+		// init would write to. The original was initialized already.
+		if rundirs[i] != workdir {
+			workdirOptions = append(workdirOptions, terraform.WithSkipInit(true))
+		}
+
 		jsonPlan, err := terraform.GetPlan(ctx, workdirOptions...)
 		if err != nil {
 			results[i].err = fmt.Errorf("failed to get plan for workdir %q: %w", workdir, err)
@@ -306,7 +731,7 @@ func getPreplannedPlans(ctx context.Context, workdirs []string, planFilename str
 
 	// First, validate that all directories have the plan file
 	for _, workdir := range workdirs {
-		planPath := filepath.Join(workdir, planFilename)
+		planPath := filepath.Join(workdir, workdirPlanFile(flag.CommandLine, workdir, planFilename))
 		if _, err := os.Stat(planPath); os.IsNotExist(err) {
 			return nil, fmt.Errorf("plan file not found: %s (all directories must have plan files when using --preplanned)", planPath)
 		}
@@ -314,13 +739,13 @@ func getPreplannedPlans(ctx context.Context, workdirs []string, planFilename str
 
 	getPlan := func(i int) {
 		workdir := workdirs[i]
-		planPath := filepath.Join(workdir, planFilename)
+		planPath := filepath.Join(workdir, workdirPlanFile(flag.CommandLine, workdir, planFilename))
 
 		os.Stderr.WriteString(pretty.Colorf("reading Terraform plan from %s...", (*pretty.Summarizer).StyledModule(nil, planPath)) + "\n")
 
 		workdirOptions := append(
-			[]terraform.Option{terraform.WithWorkdir(workdir)},
-			options...,
+			append([]terraform.Option{terraform.WithWorkdir(workdir)}, options...),
+			workdirOptions(flag.CommandLine, workdir)...,
 		)
 
 		jsonPlan, err := terraform.GetPlanFromFile(ctx, planPath, workdirOptions...)
@@ -367,6 +792,47 @@ func getPreplannedPlans(ctx context.Context, workdirs []string, planFilename str
 	return plans, nil
 }
 
+// unorderedSetRules returns rules that ignore the order of the elements of
+// every attribute that the providers of the given working directories define
+// as sets.
+func unorderedSetRules(ctx context.Context, workdirs []string, options []terraform.Option) ([]engine.Rule, error) {
+	seen := make(map[string]bool)
+
+	var setRules []engine.Rule
+	for _, workdir := range workdirs {
+		// The working directory was initialized when planning, if needed.
+		workdirOptions := append(
+			append([]terraform.Option{terraform.WithWorkdir(workdir)}, options...),
+			workdirOptions(flag.CommandLine, workdir)...,
+		)
+
+		schemas, err := terraform.GetProviderSchemas(ctx, workdirOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get provider schemas for workdir %q: %w", workdir, err)
+		}
+
+		sets := terraform.SetAttributes(schemas)
+
+		resourceTypes := make([]string, 0, len(sets))
+		for t := range sets {
+			resourceTypes = append(resourceTypes, t)
+		}
+		slices.Sort(resourceTypes)
+
+		for _, t := range resourceTypes {
+			for _, attr := range sets[t] {
+				r := rules.Unordered(t, attr)
+				if !seen[r.String()] {
+					seen[r.String()] = true
+					setRules = append(setRules, r)
+				}
+			}
+		}
+	}
+
+	return setRules, nil
+}
+
 func categorizeMoves(moves []terraform.Move) (sameWorkdir, differentWorkdir []terraform.Move) {
 	for _, m := range moves {
 		if m.FromWorkdir == m.ToWorkdir {
@@ -390,24 +856,233 @@ func writeMovedBlocks(moves []terraform.Move) error {
 	}
 
 	for workdir, moves := range movesByWorkdir {
-		movesFilePath := filepath.Join(workdir, "moves.tf")
-		movesFile, err := os.OpenFile(movesFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		movesFilePath := filepath.Join(workdir, movesFile)
+
+		// Running tfautomv again must not duplicate moved blocks, or write
+		// blocks that contradict those already in the configuration.
+		existing, err := terraform.ReadMovedBlocks(workdir, configExtensions())
 		if err != nil {
-			return fmt.Errorf("failed to open %q: %w", movesFilePath, err)
+			return fmt.Errorf("failed to read existing moved blocks: %w", err)
+		}
+
+		newMoves, conflicts := terraform.MergeMovedBlocks(existing, moves)
+		for _, c := range conflicts {
+			if c.Skipped {
+				os.Stderr.WriteString(pretty.Colorf("[yellow][bold]conflict:[reset] %s, skipping this move", c) + "\n")
+			} else {
+				os.Stderr.WriteString(pretty.Colorf("[yellow][bold]note:[reset] %s", c) + "\n")
+			}
 		}
 
-		err = terraform.WriteMovedBlocks(movesFile, moves)
+		if len(newMoves) == 0 {
+			os.Stderr.WriteString(pretty.Colorf("moved blocks already present in [bold][green]%s", workdir) + "\n")
+			continue
+		}
+
+		err = appendToMovesFile(workdir, func(w io.Writer) error {
+			return terraform.WriteMovedBlocks(w, newMoves)
+		})
 		if err != nil {
 			return fmt.Errorf("failed to write moved blocks: %w", err)
 		}
 
-		os.Stderr.WriteString(pretty.Colorf("%s written to [bold][green]%s", pretty.StyledNumMoves(len(moves)), movesFilePath))
+		os.Stderr.WriteString(pretty.Colorf("%s written to [bold][green]%s", pretty.StyledNumMoves(len(newMoves)), movesFilePath))
+		os.Stderr.WriteString("\n")
+	}
+
+	return nil
+}
+
+// openTofu is whether the configured binary is OpenTofu rather than Terraform,
+// as detected from its version command.
+var openTofu bool
+
+// nativeExtensions returns the extensions of the configuration files in native
+// syntax the configured binary reads. tfautomv only writes those.
+func nativeExtensions() []string {
+	if openTofu {
+		return []string{".tf", ".tofu"}
+	}
+	return []string{".tf"}
+}
+
+// defaultMovesFile returns the name of the file moved blocks are written to
+// when the user does not choose one. OpenTofu users get a file only OpenTofu
+// reads.
+func defaultMovesFile() string {
+	if openTofu {
+		return "moves.tofu"
+	}
+	return "moves.tf"
+}
+
+// configExtensions returns the extensions of all the configuration files the
+// configured binary reads, in native or JSON syntax.
+func configExtensions() []string {
+	var extensions []string
+	for _, ext := range nativeExtensions() {
+		extensions = append(extensions, ext, ext+".json")
+	}
+	return extensions
+}
+
+// withImportIDs sets the import ID and identity of each move, based on the
+// resource being moved.
+func withImportIDs(moves []terraform.Move, comparisons []engine.ResourceComparison) []terraform.Move {
+	deleted := make(map[string]engine.Resource)
+	for _, c := range comparisons {
+		deleted[c.ToDelete.ID()] = c.ToDelete
+	}
+
+	for i, m := range moves {
+		r, ok := deleted[engine.Resource{ModuleID: m.FromWorkdir, Address: m.FromAddress}.ID()]
+		if !ok {
+			continue
+		}
+
+		moves[i].ImportID = r.ImportID()
+
+		// Identities with nested attributes cannot be written as import
+		// blocks, so we fall back to the import ID for those.
+		flat := true
+		for k := range r.Identity {
+			if strings.Contains(k, ".") {
+				flat = false
+			}
+		}
+		if flat {
+			moves[i].ImportIdentity = r.Identity
+		}
+	}
+
+	return moves
+}
+
+// writeRemovedAndImportBlocks moves resources across working directories by
+// writing a removed block in the source directory and an import block in the
+// destination directory. Resources without an import ID, or with instances
+// that stay in the source directory, are skipped, with a warning. Blocks
+// already present in the configuration, from an earlier run, are not written
+// again.
+func writeRemovedAndImportBlocks(moves []terraform.Move, plan engine.Plan) error {
+	instances := make(map[string][]string)
+	for _, resources := range [][]engine.Resource{plan.ToCreate, plan.ToDelete, plan.ToKeep} {
+		for _, r := range resources {
+			instances[r.ModuleID] = append(instances[r.ModuleID], r.Address)
+		}
+	}
+
+	// A removed block would forget the instances that are not moved too.
+	moves, partial := terraform.SplitPartialRemovals(moves, instances)
+	for _, m := range partial {
+		os.Stderr.WriteString(pretty.Colorf("[yellow][bold]warning:[reset] other instances of %s stay in %s, so it cannot be removed with a removed block; move it to %s manually", m.FromAddress, m.FromWorkdir, m.ToAddress) + "\n")
+	}
+
+	removedByWorkdir := make(map[string][]terraform.Move)
+	importsByWorkdir := make(map[string][]terraform.Move)
+	for _, m := range moves {
+		if m.ImportID == "" && len(m.ImportIdentity) == 0 {
+			os.Stderr.WriteString(pretty.Colorf("[yellow][bold]warning:[reset] cannot determine an import ID for %s, move it to %s manually", m.FromAddress, m.ToAddress) + "\n")
+			continue
+		}
+
+		removedByWorkdir[m.FromWorkdir] = append(removedByWorkdir[m.FromWorkdir], m)
+		importsByWorkdir[m.ToWorkdir] = append(importsByWorkdir[m.ToWorkdir], m)
+	}
+
+	// Running tfautomv again must not duplicate removed or import blocks,
+	// which Terraform rejects.
+	removedByWorkdir, importsByWorkdir, err := withoutExistingBlocks(removedByWorkdir, importsByWorkdir)
+	if err != nil {
+		return err
+	}
+
+	for workdir, moves := range removedByWorkdir {
+		err := appendToMovesFile(workdir, func(w io.Writer) error {
+			return terraform.WriteRemovedBlocks(w, moves)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write removed blocks: %w", err)
+		}
+	}
+
+	for workdir, moves := range importsByWorkdir {
+		err := appendToMovesFile(workdir, func(w io.Writer) error {
+			return terraform.WriteImportBlocks(w, moves)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write import blocks: %w", err)
+		}
+
+		os.Stderr.WriteString(pretty.Colorf("%s written to [bold][green]%s", pretty.StyledNumMoves(len(moves)), filepath.Join(workdir, movesFile)))
 		os.Stderr.WriteString("\n")
 	}
 
 	return nil
 }
 
+// withoutExistingBlocks leaves out the moves that the removed and import
+// blocks in each working directory's configuration already cover.
+func withoutExistingBlocks(removedByWorkdir, importsByWorkdir map[string][]terraform.Move) (map[string][]terraform.Move, map[string][]terraform.Move, error) {
+	removed := make(map[string]map[string]bool)
+	imported := make(map[string]map[string]bool)
+	for _, byWorkdir := range []map[string][]terraform.Move{removedByWorkdir, importsByWorkdir} {
+		for workdir := range byWorkdir {
+			if _, ok := removed[workdir]; ok {
+				continue
+			}
+
+			r, i, err := terraform.ReadRemovedAndImportBlocks(workdir, configExtensions())
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read existing removed and import blocks: %w", err)
+			}
+
+			removed[workdir] = make(map[string]bool)
+			for _, address := range r {
+				removed[workdir][address] = true
+			}
+			imported[workdir] = make(map[string]bool)
+			for _, address := range i {
+				imported[workdir][address] = true
+			}
+		}
+	}
+
+	newRemoved := make(map[string][]terraform.Move)
+	for workdir, moves := range removedByWorkdir {
+		for _, m := range moves {
+			if removed[workdir][engine.ConfigAddress(m.FromAddress)] {
+				continue
+			}
+			newRemoved[workdir] = append(newRemoved[workdir], m)
+		}
+	}
+
+	newImports := make(map[string][]terraform.Move)
+	for workdir, moves := range importsByWorkdir {
+		for _, m := range moves {
+			if imported[workdir][m.ToAddress] {
+				os.Stderr.WriteString(pretty.Colorf("import block for %s already present in [bold][green]%s", m.ToAddress, workdir) + "\n")
+				continue
+			}
+			newImports[workdir] = append(newImports[workdir], m)
+		}
+	}
+
+	return newRemoved, newImports, nil
+}
+
+func appendToMovesFile(workdir string, write func(io.Writer) error) error {
+	movesFilePath := filepath.Join(workdir, movesFile)
+	movesFile, err := os.OpenFile(movesFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", movesFilePath, err)
+	}
+	defer movesFile.Close()
+
+	return write(movesFile)
+}
+
 func writeMoveCommands(moves []terraform.Move, options ...terraform.Option) error {
 	if len(moves) == 0 {
 		return nil
@@ -423,3 +1098,86 @@ func writeMoveCommands(moves []terraform.Move, options ...terraform.Option) erro
 
 	return nil
 }
+
+// writeReportFile writes a JSON report of the engine's findings to the given
+// path.
+func writeReportFile(path string, plan engine.Plan, moves, emittedMoves []engine.Move, comparisons []engine.ResourceComparison) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %q: %w", path, err)
+	}
+	defer f.Close()
+
+	err = report.New(plan, moves, emittedMoves, comparisons, strings.TrimSpace(tfautomvVersion)).Write(f)
+	if err != nil {
+		return fmt.Errorf("failed to write report to %q: %w", path, err)
+	}
+
+	os.Stderr.WriteString(pretty.Colorf("report written to [bold][green]%s", path) + "\n")
+
+	return nil
+}
+
+// writeMarkdownReport writes a Markdown report of the engine's findings to the
+// given path, including the moved blocks tfautomv would write.
+func writeMarkdownReport(path string, summarizer *pretty.Summarizer, moves []terraform.Move, userRules []engine.Rule) error {
+	sameWorkdir, _ := categorizeMoves(moves)
+	independent, _ := terraform.SplitChains(sameWorkdir)
+
+	var workdirs []string
+	movesByWorkdir := make(map[string][]terraform.Move)
+	for _, m := range independent {
+		if _, ok := movesByWorkdir[m.FromWorkdir]; !ok {
+			workdirs = append(workdirs, m.FromWorkdir)
+		}
+		movesByWorkdir[m.FromWorkdir] = append(movesByWorkdir[m.FromWorkdir], m)
+	}
+	slices.Sort(workdirs)
+
+	// With several working directories, readers need to know where each
+	// block goes.
+	var blocks strings.Builder
+	for _, workdir := range workdirs {
+		if len(workdirs) > 1 {
+			fmt.Fprintf(&blocks, "# %s\n", filepath.Join(workdir, movesFile))
+		}
+		if err := terraform.WriteMovedBlocks(&blocks, movesByWorkdir[workdir]); err != nil {
+			return fmt.Errorf("failed to write moved blocks: %w", err)
+		}
+	}
+
+	// Rules from --ignore-empty and --unordered-sets are listed along with
+	// those passed with --ignore.
+	var ruleStrings []string
+	for _, r := range userRules {
+		ruleStrings = append(ruleStrings, r.String())
+	}
+
+	err := os.WriteFile(path, []byte(summarizer.Markdown(ruleStrings, blocks.String())), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write Markdown report to %q: %w", path, err)
+	}
+
+	os.Stderr.WriteString(pretty.Colorf("Markdown report written to [bold][green]%s", path) + "\n")
+
+	return nil
+}
+
+// writeHTMLReport writes a self-contained HTML page to explore the engine's
+// findings to the given path.
+func writeHTMLReport(path string, plan engine.Plan, moves []engine.Move, comparisons []engine.ResourceComparison) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %q: %w", path, err)
+	}
+	defer f.Close()
+
+	err = report.WriteHTML(f, plan, moves, comparisons, strings.TrimSpace(tfautomvVersion))
+	if err != nil {
+		return fmt.Errorf("failed to write HTML report to %q: %w", path, err)
+	}
+
+	os.Stderr.WriteString(pretty.Colorf("HTML report written to [bold][green]%s", path) + "\n")
+
+	return nil
+}
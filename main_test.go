@@ -0,0 +1,259 @@
+package main
+
+import (
+	"testing"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/busser/tfautomv/pkg/engine"
+	"github.com/busser/tfautomv/pkg/engine/rules"
+)
+
+func TestParseListFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+
+		wantIgnoreRules      []string
+		wantTypeEquivalences []string
+	}{
+		{
+			name:            "rule with a comma",
+			args:            []string{"--ignore=regex:aws_iam_role:name:^a{1,3}$:b"},
+			wantIgnoreRules: []string{"regex:aws_iam_role:name:^a{1,3}$:b"},
+		},
+		{
+			name: "repeated rules",
+			args: []string{
+				"--ignore", "regex:aws_iam_role:name:^a{1,3}$:b",
+				"--ignore", "everything:random_pet:length",
+			},
+			wantIgnoreRules: []string{"regex:aws_iam_role:name:^a{1,3}$:b", "everything:random_pet:length"},
+		},
+		{
+			name:                 "equivalence with several attributes",
+			args:                 []string{"--type-equivalence=google_foo:google_bar:a=b,c=d"},
+			wantTypeEquivalences: []string{"google_foo:google_bar:a=b,c=d"},
+		},
+		{
+			name: "repeated equivalences",
+			args: []string{
+				"--type-equivalence", "google_foo:google_bar:a=b,c=d",
+				"--type-equivalence", "google_baz:google_qux",
+			},
+			wantTypeEquivalences: []string{"google_foo:google_bar:a=b,c=d", "google_baz:google_qux"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("tfautomv", flag.ContinueOnError)
+			registerFlags(flags)
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.wantIgnoreRules, ignoreRules); diff != "" {
+				t.Errorf("ignore rules mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantTypeEquivalences, typeEquivalences); diff != "" {
+				t.Errorf("type equivalences mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseListValueEquivalences(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "single equivalence",
+			raw:  "google_foo:google_bar:a=b,c=d",
+			want: []string{"google_foo:google_bar:a=b,c=d"},
+		},
+		{
+			name: "comma-separated equivalences",
+			raw:  "google_foo:google_bar:a=b,google_baz:google_qux",
+			want: []string{"google_foo:google_bar:a=b", "google_baz:google_qux"},
+		},
+		{
+			name:    "invalid equivalence",
+			raw:     "google_foo:google_bar:a=b,c",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parseListValue(tt.raw, engine.ParseTypeEquivalence)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, eq := range parsed {
+				got = append(got, eq.String())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("equivalences mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseListValueRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "single rule",
+			raw:  "everything:random_pet:length",
+			want: []string{"everything:random_pet:length"},
+		},
+		{
+			name: "rule with a comma",
+			raw:  "regex:aws_iam_role:name:^a{1,3}$:b",
+			want: []string{"regex:aws_iam_role:name:^a{1,3}$:b"},
+		},
+		{
+			name: "comma-separated rules",
+			raw:  "everything:random_pet:length,whitespace:aws_iam_policy:policy",
+			want: []string{"everything:random_pet:length", "whitespace:aws_iam_policy:policy"},
+		},
+		{
+			name:    "invalid rule",
+			raw:     "everything:random_pet:length,foo:bar",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parseListValue(tt.raw, rules.Parse)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, r := range parsed {
+				got = append(got, r.String())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("rules mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApplyEnvironmentLists(t *testing.T) {
+	t.Setenv("TFAUTOMV_IGNORE", "regex:aws_iam_role:name:^a{1,3}$:b\neverything:random_pet:length")
+	t.Setenv("TFAUTOMV_TYPE_EQUIVALENCE", "google_foo:google_bar:a=b,c=d\ngoogle_baz:google_qux")
+
+	flags := flag.NewFlagSet("tfautomv", flag.ContinueOnError)
+	registerFlags(flags)
+	if err := flags.Parse(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := applyEnvironment(flags); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantIgnoreRules := []string{"regex:aws_iam_role:name:^a{1,3}$:b", "everything:random_pet:length"}
+	if diff := cmp.Diff(wantIgnoreRules, ignoreRules); diff != "" {
+		t.Errorf("ignore rules mismatch (-want +got):\n%s", diff)
+	}
+
+	want := []string{"google_foo:google_bar:a=b,c=d", "google_baz:google_qux"}
+	if diff := cmp.Diff(want, typeEquivalences); diff != "" {
+		t.Errorf("type equivalences mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+
+		wantExplain        bool
+		wantExplainAddress string
+		wantWorkdirs       []string
+		wantErr            bool
+	}{
+		{
+			name:         "workdirs",
+			args:         []string{"prod", "staging"},
+			wantWorkdirs: []string{"prod", "staging"},
+		},
+		{
+			name:               "explain",
+			args:               []string{"explain", "aws_instance.web", "prod"},
+			wantExplain:        true,
+			wantExplainAddress: "aws_instance.web",
+			wantWorkdirs:       []string{"prod"},
+		},
+		{
+			name:               "explain after flags",
+			args:               []string{"--ignore=everything:random_pet:length", "explain", "aws_instance.web"},
+			wantExplain:        true,
+			wantExplainAddress: "aws_instance.web",
+			wantWorkdirs:       []string{},
+		},
+		{
+			name:    "explain without address",
+			args:    []string{"-v", "explain"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("tfautomv", flag.ContinueOnError)
+			registerFlags(flags)
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			explain, explainAddress, workdirs, err := parseArgs(flags.Args())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if explain != tt.wantExplain {
+				t.Errorf("explain = %v, want %v", explain, tt.wantExplain)
+			}
+			if explainAddress != tt.wantExplainAddress {
+				t.Errorf("explain address = %q, want %q", explainAddress, tt.wantExplainAddress)
+			}
+			if diff := cmp.Diff(tt.wantWorkdirs, workdirs); diff != "" {
+				t.Errorf("workdirs mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Package config reads tfautomv's project configuration file, which holds
+// settings a team would otherwise repeat on every command line.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// FileName is the name of the configuration file. Find looks for it in a
+// directory and its parents.
+const FileName = ".tfautomv.hcl"
+
+// A Config holds the settings of a configuration file. Each setting has the
+// same meaning as the command-line flag of the same name, with dashes
+// replaced by underscores. Settings the file does not set are nil.
+type Config struct {
+	Ignore           []string `hcl:"ignore,optional"`
+	TypeEquivalences []string `hcl:"type_equivalence,optional"`
+
+	Assignment     *string  `hcl:"assignment,optional"`
+	IgnoreEmpty    *bool    `hcl:"ignore_empty,optional"`
+	MinSimilarity  *float64 `hcl:"min_similarity,optional"`
+	MovesFile      *string  `hcl:"moves_file,optional"`
+	Output         *string  `hcl:"output,optional"`
+	Preplanned     *bool    `hcl:"preplanned,optional"`
+	PreplannedFile *string  `hcl:"preplanned_file,optional"`
+	SkipInit       *bool    `hcl:"skip_init,optional"`
+	SkipRefresh    *bool    `hcl:"skip_refresh,optional"`
+	Strict         *bool    `hcl:"strict,optional"`
+	TerraformBin   *string  `hcl:"terraform_bin,optional"`
+	UnorderedSets  *bool    `hcl:"unordered_sets,optional"`
+
+	// Settings that only apply to a single working directory.
+	Workdirs []Workdir `hcl:"workdir,block"`
+}
+
+// A Workdir holds settings that override the top-level ones for a single
+// working directory.
+type Workdir struct {
+	// The working directory's path. Relative paths in the file are relative to
+	// the file's directory. Once loaded, the path is absolute.
+	Path string `hcl:"path,label"`
+
+	PreplannedFile *string `hcl:"preplanned_file,optional"`
+	SkipInit       *bool   `hcl:"skip_init,optional"`
+	SkipRefresh    *bool   `hcl:"skip_refresh,optional"`
+}
+
+// Find looks for a configuration file in the given directory, then in each
+// of its parents. It returns the path to the first file found, or an empty
+// string if there is none.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %q: %w", dir, err)
+	}
+
+	for {
+		path := filepath.Join(dir, FileName)
+
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to check for %q: %w", path, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads and parses the configuration file at the given path.
+func Load(path string) (*Config, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", path, err)
+	}
+
+	return Parse(src, path)
+}
+
+// Parse parses the contents of a configuration file. The filename is used in
+// error messages, and to resolve the paths of working directories.
+func Parse(src []byte, filename string) (*Config, error) {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %q: %w", filename, diags)
+	}
+
+	var cfg Config
+	if diags := gohcl.DecodeBody(file.Body, nil, &cfg); diags.HasErrors() {
+		return nil, fmt.Errorf("invalid configuration in %q: %w", filename, diags)
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %q: %w", filename, err)
+	}
+
+	seen := make(map[string]bool)
+	for i, w := range cfg.Workdirs {
+		if !filepath.IsAbs(w.Path) {
+			w.Path = filepath.Join(dir, w.Path)
+		}
+		w.Path = filepath.Clean(w.Path)
+
+		if seen[w.Path] {
+			return nil, fmt.Errorf("invalid configuration in %q: working directory %q is configured more than once", filename, cfg.Workdirs[i].Path)
+		}
+		seen[w.Path] = true
+
+		cfg.Workdirs[i] = w
+	}
+
+	return &cfg, nil
+}
+
+// Workdir returns the settings specific to the given working directory, and
+// whether there are any.
+func (c *Config) Workdir(path string) (Workdir, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Workdir{}, false
+	}
+
+	for _, w := range c.Workdirs {
+		if w.Path == abs {
+			return w, true
+		}
+	}
+
+	return Workdir{}, false
+}
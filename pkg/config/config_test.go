@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "live", "prod")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(root, "live", FileName)
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Find(nested)
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+	if got != path {
+		t.Errorf("Find() = %q, want %q", got, path)
+	}
+}
+
+func TestParse(t *testing.T) {
+	src := `
+ignore = [
+  "everything:aws_instance:tags.Name",
+  "whitespace:aws_iam_policy:policy",
+]
+output        = "blocks"
+terraform_bin = "tofu"
+preplanned    = true
+
+workdir "prod" {
+  preplanned_file = "prod.tfplan"
+  skip_init       = true
+}
+
+workdir "/abs/staging" {
+  skip_refresh = false
+}
+`
+
+	dir := t.TempDir()
+	got, err := Parse([]byte(src), filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	want := &Config{
+		Ignore: []string{
+			"everything:aws_instance:tags.Name",
+			"whitespace:aws_iam_policy:policy",
+		},
+		Output:       ptr("blocks"),
+		TerraformBin: ptr("tofu"),
+		Preplanned:   ptr(true),
+		Workdirs: []Workdir{
+			{Path: filepath.Join(dir, "prod"), PreplannedFile: ptr("prod.tfplan"), SkipInit: ptr(true)},
+			{Path: "/abs/staging", SkipRefresh: ptr(false)},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	w, ok := got.Workdir(filepath.Join(dir, "prod", "."))
+	if !ok || w.Path != filepath.Join(dir, "prod") {
+		t.Errorf("Workdir() = %v, %v, want settings for %q", w, ok, filepath.Join(dir, "prod"))
+	}
+
+	if _, ok := got.Workdir(filepath.Join(dir, "dev")); ok {
+		t.Errorf("Workdir() found settings for an unconfigured working directory")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{
+			name: "syntax error",
+			src:  `ignore = [`,
+		},
+		{
+			name: "unknown setting",
+			src:  `verbosity = 3`,
+		},
+		{
+			name: "wrong type",
+			src:  `skip_init = "yes please"`,
+		},
+		{
+			name: "setting not supported per working directory",
+			src:  `workdir "prod" { output = "blocks" }`,
+		},
+		{
+			name: "duplicate working directory",
+			src:  "workdir \"prod\" {}\nworkdir \"./prod\" {}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.src), FileName); err == nil {
+				t.Errorf("Parse() succeeded, want error")
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,131 @@
+package engine
+
+import "strings"
+
+// instanceKeys returns the positions of the instance keys in the given
+// address, brackets included. Brackets and quotes within quoted keys are
+// ignored. For example, `module.a[0].aws_instance.b["c]"]` has keys at
+// [8, 11) and [26, 32).
+func instanceKeys(address string) [][2]int {
+	var keys [][2]int
+
+	depth := 0
+	inString := false
+	escaped := false
+	start := 0
+
+	for i, ch := range address {
+		switch {
+		case inString && escaped:
+			escaped = false
+		case inString && ch == '\\':
+			escaped = true
+		case ch == '"' && depth > 0:
+			inString = !inString
+		case inString:
+		case ch == '[':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case ch == ']':
+			depth--
+			if depth == 0 {
+				keys = append(keys, [2]int{start, i + 1})
+			}
+		}
+	}
+
+	return keys
+}
+
+// ConfigAddress returns the address of the resource block an instance was
+// declared by, by removing all instance keys from the given address. For
+// example, `module.a[0].aws_instance.b["c"]` becomes `module.a.aws_instance.b`.
+func ConfigAddress(address string) string {
+	var b strings.Builder
+	b.Grow(len(address))
+
+	end := 0
+	for _, key := range instanceKeys(address) {
+		b.WriteString(address[end:key[0]])
+		end = key[1]
+	}
+	b.WriteString(address[end:])
+
+	return b.String()
+}
+
+// resourceTypeOf returns the resource type in the given configuration address.
+// Data sources are prefixed with "data.", so that they are never confused with
+// managed resources of the same type.
+func resourceTypeOf(address string) string {
+	parts := strings.Split(address, ".")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	typ := parts[len(parts)-2]
+
+	// A module can be named "data", so we check that "data" is not a module
+	// name before treating the address as a data source.
+	isData := len(parts) >= 3 && parts[len(parts)-3] == "data"
+	if isData && len(parts) >= 4 && parts[len(parts)-4] == "module" {
+		isData = false
+	}
+	if isData {
+		typ = "data." + typ
+	}
+
+	return typ
+}
+
+// splitAddress splits the given address into its dot-separated parts, ignoring
+// dots within instance keys. For example, `module.a["b.c"].aws_instance.d`
+// becomes ["module", `a["b.c"]`, "aws_instance", "d"].
+func splitAddress(address string) []string {
+	var parts []string
+
+	keys := instanceKeys(address)
+	start := 0
+	for i, ch := range address {
+		for len(keys) > 0 && keys[0][1] <= i {
+			keys = keys[1:]
+		}
+		if ch == '.' && (len(keys) == 0 || i < keys[0][0]) {
+			parts = append(parts, address[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, address[start:])
+}
+
+// modulePrefixes returns the addresses of the module instances the given
+// resource address is nested in, from outermost to innermost. For example,
+// `module.a[0].module.b.aws_instance.c` has prefixes `module.a[0]` and
+// `module.a[0].module.b`.
+func modulePrefixes(address string) []string {
+	parts := splitAddress(address)
+
+	var prefixes []string
+	for i := 0; i+2 < len(parts) && parts[i] == "module"; i += 2 {
+		prefixes = append(prefixes, strings.Join(parts[:i+2], "."))
+	}
+
+	return prefixes
+}
+
+// InstanceKey splits the given address into the address of the resource and
+// the resource's instance key. For example, `module.a[0].aws_instance.b["c"]`
+// becomes `module.a[0].aws_instance.b` and `"c"`. The key is empty if the
+// resource has none.
+func InstanceKey(address string) (resource, key string) {
+	keys := instanceKeys(address)
+	if len(keys) == 0 || keys[len(keys)-1][1] != len(address) {
+		return address, ""
+	}
+
+	last := keys[len(keys)-1]
+	return address[:last[0]], address[last[0]+1 : last[1]-1]
+}
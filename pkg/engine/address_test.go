@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConfigAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"aws_instance.web", "aws_instance.web"},
+		{"aws_instance.web[0]", "aws_instance.web"},
+		{`aws_instance.web["a"]`, "aws_instance.web"},
+		{`module.a[0].aws_instance.web["b"]`, "module.a.aws_instance.web"},
+		{`aws_instance.web["with]bracket"]`, "aws_instance.web"},
+		{`aws_instance.web["with\"quote]"]`, "aws_instance.web"},
+		{"data.aws_ami.ubuntu[1]", "data.aws_ami.ubuntu"},
+	}
+
+	for _, tt := range tests {
+		if got := ConfigAddress(tt.address); got != tt.want {
+			t.Errorf("ConfigAddress(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestResourceTypeOf(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"aws_instance.web", "aws_instance"},
+		{"module.a.aws_instance.web", "aws_instance"},
+		{"data.aws_ami.ubuntu", "data.aws_ami"},
+		{"module.a.data.aws_ami.ubuntu", "data.aws_ami"},
+		{"module.data.aws_instance.web", "aws_instance"},
+		{"invalid", ""},
+	}
+
+	for _, tt := range tests {
+		if got := resourceTypeOf(tt.address); got != tt.want {
+			t.Errorf("resourceTypeOf(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestResourceReference(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"aws_instance.web", "aws_instance.web"},
+		{"aws_instance.web.id", "aws_instance.web"},
+		{"aws_instance.web[0].id", "aws_instance.web"},
+		{"data.aws_ami.ubuntu.id", "data.aws_ami.ubuntu"},
+		{"var.name", ""},
+		{"local.name", ""},
+		{"module.child.output", ""},
+		{"each.value", ""},
+		{"count.index", ""},
+		{"path.module", ""},
+	}
+
+	for _, tt := range tests {
+		if got := resourceReference(tt.ref); got != tt.want {
+			t.Errorf("resourceReference(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestModulePrefixes(t *testing.T) {
+	tests := []struct {
+		address string
+		want    []string
+	}{
+		{"aws_instance.web", nil},
+		{"module.a.aws_instance.web", []string{"module.a"}},
+		{`module.a[0].module.b["x.y"].aws_instance.web[1]`, []string{"module.a[0]", `module.a[0].module.b["x.y"]`}},
+		{"module.a.data.aws_ami.ubuntu", []string{"module.a"}},
+		{"module.a", nil},
+	}
+
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, modulePrefixes(tt.address)); diff != "" {
+			t.Errorf("modulePrefixes(%q) mismatch (-want +got):\n%s", tt.address, diff)
+		}
+	}
+}
+
+func TestInstanceKey(t *testing.T) {
+	tests := []struct {
+		address      string
+		wantResource string
+		wantKey      string
+	}{
+		{"aws_instance.web", "aws_instance.web", ""},
+		{"aws_instance.web[0]", "aws_instance.web", "0"},
+		{`aws_instance.web["a"]`, "aws_instance.web", `"a"`},
+		{`aws_instance.web["with]bracket"]`, "aws_instance.web", `"with]bracket"`},
+		{`module.a[0].aws_instance.web`, `module.a[0].aws_instance.web`, ""},
+		{`module.a[0].aws_instance.web["b"]`, `module.a[0].aws_instance.web`, `"b"`},
+	}
+
+	for _, tt := range tests {
+		resource, key := InstanceKey(tt.address)
+		if resource != tt.wantResource || key != tt.wantKey {
+			t.Errorf("InstanceKey(%q) = (%q, %q), want (%q, %q)", tt.address, resource, key, tt.wantResource, tt.wantKey)
+		}
+	}
+}
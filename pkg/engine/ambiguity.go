@@ -0,0 +1,66 @@
+package engine
+
+import "sort"
+
+// An AmbiguousGroup is a set of resources linked to each other by matches,
+// among which at least one resource matches several others and was not moved.
+// tfautomv cannot tell which of those resources correspond to each other.
+type AmbiguousGroup struct {
+	// The resources of the group Terraform plans to create.
+	ToCreate []Resource
+	// The resources of the group Terraform plans to delete.
+	ToDelete []Resource
+}
+
+// AmbiguousGroups returns the groups of resources that match each other
+// ambiguously, given the comparisons made and the moves chosen from them.
+func AmbiguousGroups(comparisons []ResourceComparison, moves []Move) []AmbiguousGroup {
+	var matches []ResourceComparison
+	matchCount := make(map[string]int)
+	for _, c := range comparisons {
+		if c.IsMatch() {
+			matches = append(matches, c)
+			matchCount["+"+c.ToCreate.ID()]++
+			matchCount["-"+c.ToDelete.ID()]++
+		}
+	}
+
+	moved := make(map[string]bool)
+	for _, m := range moves {
+		moved["+"+Resource{ModuleID: m.DestinationModule, Address: m.DestinationAddress}.ID()] = true
+		moved["-"+Resource{ModuleID: m.SourceModule, Address: m.SourceAddress}.ID()] = true
+	}
+
+	var groups []AmbiguousGroup
+	for _, g := range matchGroups(matches) {
+		ambiguous := false
+		for _, k := range append(append([]string(nil), g.toCreate...), g.toDelete...) {
+			if matchCount[k] > 1 && !moved[k] {
+				ambiguous = true
+			}
+		}
+		if !ambiguous {
+			continue
+		}
+
+		toCreate := make([]Resource, len(g.toCreate))
+		toDelete := make([]Resource, len(g.toDelete))
+		for pos, c := range g.edges {
+			toCreate[pos[0]] = c.ToCreate
+			toDelete[pos[1]] = c.ToDelete
+		}
+
+		sortResources(toCreate)
+		sortResources(toDelete)
+
+		groups = append(groups, AmbiguousGroup{ToCreate: toCreate, ToDelete: toDelete})
+	}
+
+	return groups
+}
+
+func sortResources(resources []Resource) {
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].ID() < resources[j].ID()
+	})
+}
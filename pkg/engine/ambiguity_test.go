@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAmbiguousGroups(t *testing.T) {
+	comparisons := []ResourceComparison{
+		// Exclusive match.
+		dummyComparison("new_a", "old_a", 1, 0),
+
+		// Two identical resources were renamed.
+		dummyComparison("new_b", "old_b", 1, 0),
+		dummyComparison("new_b", "old_c", 1, 0),
+		dummyComparison("new_c", "old_b", 1, 0),
+		dummyComparison("new_c", "old_c", 1, 0),
+
+		// A resource matches two others, but was moved anyway.
+		dummyComparison("new_d", "old_d", 2, 0),
+		dummyComparison("new_d", "old_e", 1, 0),
+
+		// Not a match.
+		dummyComparison("new_e", "old_f", 3, 1),
+	}
+
+	moves := []Move{
+		dummyMove("old_a", "new_a"),
+		dummyMove("old_d", "new_d"),
+	}
+
+	want := []AmbiguousGroup{
+		{
+			ToCreate: []Resource{dummyResource("", "", "new_b"), dummyResource("", "", "new_c")},
+			ToDelete: []Resource{dummyResource("", "", "old_b"), dummyResource("", "", "old_c")},
+		},
+	}
+
+	got := AmbiguousGroups(comparisons, moves)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
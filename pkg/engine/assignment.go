@@ -0,0 +1,411 @@
+package engine
+
+import (
+	"math"
+	"sort"
+)
+
+// An Assignment is a strategy for pairing resources planned for creation with
+// resources planned for deletion when some of them match more than one other
+// resource.
+type Assignment string
+
+const (
+	// AssignmentExclusive only pairs resources that match each other and only
+	// each other. Resources with more than one match are never moved.
+	AssignmentExclusive Assignment = "exclusive"
+
+	// AssignmentOptimal scores each matching pair of resources and looks for
+	// the one-to-one pairing with the highest total score. A pair is only
+	// moved if it is part of every such pairing, which means that no other
+	// pairing is as good.
+	AssignmentOptimal Assignment = "optimal"
+
+	// AssignmentBestEffort works like AssignmentOptimal, but moves every pair
+	// of the best pairing found, even when other pairings are equally good.
+	// These moves are marked as best effort.
+	AssignmentBestEffort Assignment = "best-effort"
+)
+
+// Assignments lists all supported assignment strategies.
+var Assignments = []Assignment{
+	AssignmentExclusive,
+	AssignmentOptimal,
+	AssignmentBestEffort,
+}
+
+// assign pairs the resources of the given candidate comparisons according to
+// the given strategy.
+func assign(candidates []ResourceComparison, strategy Assignment) []Move {
+	switch strategy {
+	case AssignmentOptimal:
+		return assignedMoves(candidates, false)
+	case AssignmentBestEffort:
+		return assignedMoves(candidates, true)
+	default:
+		return exclusiveMoves(candidates)
+	}
+}
+
+// exclusiveMoves returns a move for each pair of candidate resources that
+// match each other and only each other.
+func exclusiveMoves(candidates []ResourceComparison) []Move {
+	planToCreateMatchCount := make(map[string]int)
+	planToDeleteMatchCount := make(map[string]int)
+	for _, comparison := range candidates {
+		planToCreateMatchCount[comparison.ToCreate.ID()]++
+		planToDeleteMatchCount[comparison.ToDelete.ID()]++
+	}
+
+	var moves []Move
+
+	for _, comparison := range candidates {
+		if planToCreateMatchCount[comparison.ToCreate.ID()] != 1 {
+			continue
+		}
+
+		if planToDeleteMatchCount[comparison.ToDelete.ID()] != 1 {
+			continue
+		}
+
+		moves = append(moves, moveFromComparison(comparison))
+	}
+
+	return moves
+}
+
+// assignedMoves pairs candidate resources so that each resource is moved at
+// most once and the total score of all pairs is as high as possible. Pairs
+// that other equally good pairings do not agree on are only moved when
+// bestEffort is true.
+func assignedMoves(candidates []ResourceComparison, bestEffort bool) []Move {
+	var moves []Move
+
+	for _, group := range matchGroups(candidates) {
+		for _, p := range group.assign() {
+			if !p.unique && !bestEffort {
+				continue
+			}
+
+			m := moveFromComparison(p.comparison)
+			m.BestEffort = !p.unique
+			moves = append(moves, m)
+		}
+	}
+
+	return moves
+}
+
+// A matchGroup is a set of resources linked to each other by candidate
+// comparisons. Resources in different groups never compete with each other,
+// so each group can be assigned on its own.
+type matchGroup struct {
+	toCreate []string
+	toDelete []string
+
+	// Candidate comparisons, indexed by position in toCreate and toDelete.
+	edges map[[2]int]ResourceComparison
+}
+
+type pairing struct {
+	comparison ResourceComparison
+
+	// Whether this pair is part of every best pairing of the group.
+	unique bool
+}
+
+func matchGroups(candidates []ResourceComparison) []matchGroup {
+	// Resources planned for creation and deletion can share an ID, so we
+	// prefix IDs to tell them apart.
+	createKey := func(r Resource) string { return "+" + r.ID() }
+	deleteKey := func(r Resource) string { return "-" + r.ID() }
+
+	parent := make(map[string]string)
+	var find func(k string) string
+	find = func(k string) string {
+		if parent[k] == k {
+			return k
+		}
+		parent[k] = find(parent[k])
+		return parent[k]
+	}
+	union := func(a, b string) {
+		for _, k := range []string{a, b} {
+			if _, ok := parent[k]; !ok {
+				parent[k] = k
+			}
+		}
+		parent[find(a)] = find(b)
+	}
+
+	for _, c := range candidates {
+		union(createKey(c.ToCreate), deleteKey(c.ToDelete))
+	}
+
+	byRoot := make(map[string]*matchGroup)
+	var roots []string
+	createIndex := make(map[string]int)
+	deleteIndex := make(map[string]int)
+
+	for _, c := range candidates {
+		root := find(createKey(c.ToCreate))
+		g, ok := byRoot[root]
+		if !ok {
+			g = &matchGroup{edges: make(map[[2]int]ResourceComparison)}
+			byRoot[root] = g
+			roots = append(roots, root)
+		}
+
+		ck, dk := createKey(c.ToCreate), deleteKey(c.ToDelete)
+		if _, ok := createIndex[ck]; !ok {
+			createIndex[ck] = len(g.toCreate)
+			g.toCreate = append(g.toCreate, ck)
+		}
+		if _, ok := deleteIndex[dk]; !ok {
+			deleteIndex[dk] = len(g.toDelete)
+			g.toDelete = append(g.toDelete, dk)
+		}
+
+		g.edges[[2]int{createIndex[ck], deleteIndex[dk]}] = c
+	}
+
+	sort.Strings(roots)
+
+	groups := make([]matchGroup, 0, len(roots))
+	for _, root := range roots {
+		groups = append(groups, *byRoot[root])
+	}
+
+	return groups
+}
+
+// assign finds a best pairing of the group's resources. A best pairing moves
+// as many resources as possible and, among those, has the highest total
+// score.
+func (g matchGroup) assign() []pairing {
+	n := max(len(g.toCreate), len(g.toDelete))
+
+	// Every edge is worth more than all scores combined, so that moving more
+	// resources always beats moving fewer, better matching resources. This
+	// also keeps weights positive when scores are negative.
+	var totalScore float64
+	for _, c := range g.edges {
+		totalScore += math.Abs(c.score())
+	}
+	base := totalScore + 1
+
+	weights := make([][]float64, n)
+	for i := range weights {
+		weights[i] = make([]float64, n)
+	}
+	for e, c := range g.edges {
+		weights[e[0]][e[1]] = base + c.score()
+	}
+
+	best, bestWeight, slack := maxWeightMatching(weights)
+	fixed := fixedPairs(best, slack, weightEpsilon*bestWeight)
+
+	var pairings []pairing
+	for i, j := range best {
+		c, isEdge := g.edges[[2]int{i, j}]
+		if !isEdge {
+			continue
+		}
+
+		pairings = append(pairings, pairing{
+			comparison: c,
+			unique:     fixed[i],
+		})
+	}
+
+	return pairings
+}
+
+// weightEpsilon is the tolerance for rounding errors when comparing weights,
+// relative to the total weight of the best pairing. Scores differ by at least
+// ignoredWeight, so this is far below any real difference.
+const weightEpsilon = 1e-12
+
+// fixedPairs reports, for each row of the given best assignment, whether its
+// pair is part of every best assignment. Cells with a slack of at most the
+// given tolerance are the only ones any best assignment uses. A pair can be
+// swapped out if and only if it lies on a cycle that alternates between
+// such cells and pairs of the assignment: following the cycle gives another
+// assignment with the same total weight.
+//
+// The cycles are found with a single search for strongly connected
+// components, in a graph where rows point to the columns they could be paired
+// with, and columns point to the row they are paired with.
+func fixedPairs(assignment []int, slack [][]float64, tolerance float64) []bool {
+	n := len(assignment)
+
+	// Rows are nodes 0 to n-1, and columns are nodes n to 2n-1.
+	owner := make([]int, n)
+	for i, j := range assignment {
+		owner[j] = i
+	}
+	successors := func(node int, visit func(int)) {
+		if node >= n {
+			visit(owner[node-n])
+			return
+		}
+		for j := range n {
+			if j != assignment[node] && slack[node][j] <= tolerance {
+				visit(n + j)
+			}
+		}
+	}
+
+	component := stronglyConnectedComponents(2*n, successors)
+
+	fixed := make([]bool, n)
+	for i, j := range assignment {
+		fixed[i] = component[i] != component[n+j]
+	}
+	return fixed
+}
+
+// stronglyConnectedComponents labels each node of a directed graph with its
+// strongly connected component, using Tarjan's algorithm.
+func stronglyConnectedComponents(n int, successors func(node int, visit func(int))) []int {
+	const unvisited = -1
+
+	index := make([]int, n)
+	lowlink := make([]int, n)
+	component := make([]int, n)
+	onStack := make([]bool, n)
+	for i := range index {
+		index[i] = unvisited
+	}
+
+	var stack []int
+	next, components := 0, 0
+
+	var connect func(v int)
+	connect = func(v int) {
+		index[v], lowlink[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		successors(v, func(w int) {
+			switch {
+			case index[w] == unvisited:
+				connect(w)
+				lowlink[v] = min(lowlink[v], lowlink[w])
+			case onStack[w]:
+				lowlink[v] = min(lowlink[v], index[w])
+			}
+		})
+
+		if lowlink[v] == index[v] {
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component[w] = components
+				if w == v {
+					break
+				}
+			}
+			components++
+		}
+	}
+
+	for v := range n {
+		if index[v] == unvisited {
+			connect(v)
+		}
+	}
+
+	return component
+}
+
+// maxWeightMatching solves the assignment problem for the given square matrix
+// of non-negative weights, using the Hungarian algorithm. It returns, for each
+// row, the column it is assigned to, along with the total weight of the
+// assignment.
+//
+// It also returns the slack of each cell: how much weight an assignment loses
+// compared to the best one by using that cell, according to the dual
+// potentials the algorithm computes. The slack is never negative, and every
+// cell of every best assignment has a slack of zero.
+func maxWeightMatching(weights [][]float64) ([]int, float64, [][]float64) {
+	n := len(weights)
+
+	// The algorithm minimizes cost, so we negate weights. Arrays are indexed
+	// from 1, with index 0 used as a sentinel.
+	u := make([]float64, n+1)
+	v := make([]float64, n+1)
+	p := make([]int, n+1)
+	way := make([]int, n+1)
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, n+1)
+		used := make([]bool, n+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := 0
+
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				cur := -weights[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	assignment := make([]int, n)
+	var total float64
+	for j := 1; j <= n; j++ {
+		assignment[p[j]-1] = j - 1
+		total += weights[p[j]-1][j-1]
+	}
+
+	slack := make([][]float64, n)
+	for i := range slack {
+		slack[i] = make([]float64, n)
+		for j := range slack[i] {
+			slack[i][j] = -weights[i][j] - u[i+1] - v[j+1]
+		}
+	}
+
+	return assignment, total, slack
+}
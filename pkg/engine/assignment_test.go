@@ -0,0 +1,409 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestDetermineMovesWithAssignment(t *testing.T) {
+	tests := []struct {
+		name        string
+		comparisons []ResourceComparison
+
+		wantExclusive  []Move
+		wantOptimal    []Move
+		wantBestEffort []Move
+	}{
+		{
+			// When resources match each other and only each other, all
+			// strategies agree.
+			name: "single match",
+			comparisons: []ResourceComparison{
+				dummyComparison("this_address", "that_address", 1, 0),
+			},
+			wantExclusive: []Move{
+				dummyMove("that_address", "this_address"),
+			},
+			wantOptimal: []Move{
+				dummyMove("that_address", "this_address"),
+			},
+			wantBestEffort: []Move{
+				dummyMove("that_address", "this_address"),
+			},
+		},
+
+		{
+			// Two identical resources were renamed. Any pairing is as good as
+			// the other, so only best effort makes moves.
+			name: "two identical resources",
+			comparisons: []ResourceComparison{
+				dummyComparison("new_a", "old_a", 1, 0),
+				dummyComparison("new_a", "old_b", 1, 0),
+				dummyComparison("new_b", "old_a", 1, 0),
+				dummyComparison("new_b", "old_b", 1, 0),
+			},
+			wantExclusive: nil,
+			wantOptimal:   nil,
+			wantBestEffort: []Move{
+				{
+					SourceModule:       "dummy_module",
+					DestinationModule:  "dummy_module",
+					SourceAddress:      "old_a",
+					DestinationAddress: "new_a",
+					BestEffort:         true,
+				},
+				{
+					SourceModule:       "dummy_module",
+					DestinationModule:  "dummy_module",
+					SourceAddress:      "old_b",
+					DestinationAddress: "new_b",
+					BestEffort:         true,
+				},
+			},
+		},
+
+		{
+			// Each resource matches two others, but some pairs share more
+			// attributes than others. Only one pairing has the best score.
+			name: "one best pairing",
+			comparisons: []ResourceComparison{
+				dummyComparison("new_a", "old_a", 5, 0),
+				dummyComparison("new_a", "old_b", 3, 0),
+				dummyComparison("new_b", "old_a", 3, 0),
+				dummyComparison("new_b", "old_b", 5, 0),
+			},
+			wantExclusive: nil,
+			wantOptimal: []Move{
+				dummyMove("old_a", "new_a"),
+				dummyMove("old_b", "new_b"),
+			},
+			wantBestEffort: []Move{
+				dummyMove("old_a", "new_a"),
+				dummyMove("old_b", "new_b"),
+			},
+		},
+
+		{
+			// new_a matches both old resources, but new_b only matches old_b.
+			// The only way to move both is to pair new_a with old_a, even
+			// though new_a resembles old_b more.
+			name: "moving more resources beats better scores",
+			comparisons: []ResourceComparison{
+				dummyComparison("new_a", "old_a", 1, 0),
+				dummyComparison("new_a", "old_b", 10, 0),
+				dummyComparison("new_b", "old_b", 1, 0),
+			},
+			wantExclusive: nil,
+			wantOptimal: []Move{
+				dummyMove("old_a", "new_a"),
+				dummyMove("old_b", "new_b"),
+			},
+			wantBestEffort: []Move{
+				dummyMove("old_a", "new_a"),
+				dummyMove("old_b", "new_b"),
+			},
+		},
+
+		{
+			// Three resources compete for two. Whichever is left out, the
+			// pairing is as good, so nothing is provably unique.
+			name: "more candidates than resources",
+			comparisons: []ResourceComparison{
+				dummyComparison("new_a", "old_a", 1, 0),
+				dummyComparison("new_a", "old_b", 1, 0),
+				dummyComparison("new_a", "old_c", 1, 0),
+			},
+			wantExclusive: nil,
+			wantOptimal:   nil,
+			wantBestEffort: []Move{
+				{
+					SourceModule:       "dummy_module",
+					DestinationModule:  "dummy_module",
+					SourceAddress:      "old_a",
+					DestinationAddress: "new_a",
+					BestEffort:         true,
+				},
+			},
+		},
+
+		{
+			// Groups of resources that do not compete are assigned
+			// independently.
+			name: "independent groups",
+			comparisons: []ResourceComparison{
+				dummyComparison("new_a", "old_a", 1, 0),
+				dummyComparison("new_b", "old_b", 2, 0),
+				dummyComparison("new_b", "old_c", 1, 0),
+				dummyComparison("new_x", "old_x", 1, 0),
+				{
+					ToCreate:              dummyResource("", "", "new_y"),
+					ToDelete:              dummyResource("", "", "old_y"),
+					MismatchingAttributes: []string{"foo"},
+				},
+			},
+			wantExclusive: []Move{
+				dummyMove("old_a", "new_a"),
+				dummyMove("old_x", "new_x"),
+			},
+			wantOptimal: []Move{
+				dummyMove("old_a", "new_a"),
+				dummyMove("old_b", "new_b"),
+				dummyMove("old_x", "new_x"),
+			},
+			wantBestEffort: []Move{
+				dummyMove("old_a", "new_a"),
+				dummyMove("old_b", "new_b"),
+				dummyMove("old_x", "new_x"),
+			},
+		},
+		{
+			// new_a can only be paired with old_a, but new_b and new_c can
+			// swap old resources. Only the pair outside the swap is moved.
+			name: "some pairs can be swapped",
+			comparisons: []ResourceComparison{
+				dummyComparison("new_a", "old_a", 2, 0),
+				dummyComparison("new_a", "old_b", 1, 0),
+				dummyComparison("new_b", "old_b", 1, 0),
+				dummyComparison("new_b", "old_c", 1, 0),
+				dummyComparison("new_c", "old_b", 1, 0),
+				dummyComparison("new_c", "old_c", 1, 0),
+			},
+			wantExclusive: nil,
+			wantOptimal: []Move{
+				dummyMove("old_a", "new_a"),
+			},
+			wantBestEffort: []Move{
+				dummyMove("old_a", "new_a"),
+				{
+					SourceModule:       "dummy_module",
+					DestinationModule:  "dummy_module",
+					SourceAddress:      "old_b",
+					DestinationAddress: "new_b",
+					BestEffort:         true,
+				},
+				{
+					SourceModule:       "dummy_module",
+					DestinationModule:  "dummy_module",
+					SourceAddress:      "old_c",
+					DestinationAddress: "new_c",
+					BestEffort:         true,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for assignment, want := range map[Assignment][]Move{
+				AssignmentExclusive:  tt.wantExclusive,
+				AssignmentOptimal:    tt.wantOptimal,
+				AssignmentBestEffort: tt.wantBestEffort,
+			} {
+				got := DetermineMoves(tt.comparisons, WithAssignment(assignment))
+				if !slices.Equal(got, want) {
+					t.Errorf("%s: got %v, want %v", assignment, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDetermineMovesWithLargeGroup(t *testing.T) {
+	// Many identical resources were renamed. Every pairing is as good as any
+	// other, so none of them is moved.
+	const n = 200
+
+	var comparisons []ResourceComparison
+	for i := range n {
+		for j := range n {
+			comparisons = append(comparisons, dummyComparison(fmt.Sprintf("new_%d", i), fmt.Sprintf("old_%d", j), 1, 0))
+		}
+	}
+
+	if got := DetermineMoves(comparisons, WithAssignment(AssignmentOptimal)); len(got) != 0 {
+		t.Errorf("got %d moves, want none", len(got))
+	}
+	if got := DetermineMoves(comparisons, WithAssignment(AssignmentBestEffort)); len(got) != n {
+		t.Errorf("got %d moves, want %d", len(got), n)
+	}
+}
+
+func TestDetermineMovesWithMinSimilarity(t *testing.T) {
+	tests := []struct {
+		name          string
+		comparisons   []ResourceComparison
+		minSimilarity float64
+
+		wantMoves []Move
+	}{
+		{
+			// A single attribute changed, which is enough to prevent a match
+			// but not enough to fall below the threshold.
+			name: "near miss above threshold",
+			comparisons: []ResourceComparison{
+				dummyComparison("new_a", "old_a", 9, 1),
+			},
+			minSimilarity: 0.9,
+			wantMoves: []Move{
+				{
+					SourceModule:       "dummy_module",
+					DestinationModule:  "dummy_module",
+					SourceAddress:      "old_a",
+					DestinationAddress: "new_a",
+					Approximate:        true,
+				},
+			},
+		},
+
+		{
+			name: "near miss below threshold",
+			comparisons: []ResourceComparison{
+				dummyComparison("new_a", "old_a", 8, 2),
+			},
+			minSimilarity: 0.9,
+			wantMoves:     nil,
+		},
+
+		{
+			name: "disabled by default",
+			comparisons: []ResourceComparison{
+				dummyComparison("new_a", "old_a", 9, 1),
+			},
+			wantMoves: nil,
+		},
+
+		{
+			// Exact matches take precedence, and resources with a match are
+			// never considered for approximate moves.
+			name: "exact matches take precedence",
+			comparisons: []ResourceComparison{
+				dummyComparison("new_a", "old_a", 9, 0),
+				dummyComparison("new_a", "old_b", 9, 1),
+				dummyComparison("new_b", "old_a", 9, 1),
+				dummyComparison("new_b", "old_b", 9, 1),
+			},
+			minSimilarity: 0.5,
+			wantMoves: []Move{
+				dummyMove("old_a", "new_a"),
+				{
+					SourceModule:       "dummy_module",
+					DestinationModule:  "dummy_module",
+					SourceAddress:      "old_b",
+					DestinationAddress: "new_b",
+					Approximate:        true,
+				},
+			},
+		},
+
+		{
+			// Two near misses for the same resource are as ambiguous as two
+			// matches would be.
+			name: "ambiguous near misses",
+			comparisons: []ResourceComparison{
+				dummyComparison("new_a", "old_a", 9, 1),
+				dummyComparison("new_a", "old_b", 9, 1),
+			},
+			minSimilarity: 0.5,
+			wantMoves:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetermineMoves(tt.comparisons, WithMinSimilarity(tt.minSimilarity))
+			if !slices.Equal(got, tt.wantMoves) {
+				t.Errorf("got %v, want %v", got, tt.wantMoves)
+			}
+		})
+	}
+}
+
+func TestMaxWeightMatching(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights [][]float64
+
+		wantAssignment []int
+		wantTotal      float64
+	}{
+		{
+			name:           "empty",
+			weights:        [][]float64{},
+			wantAssignment: []int{},
+			wantTotal:      0,
+		},
+		{
+			name: "diagonal",
+			weights: [][]float64{
+				{3, 1},
+				{1, 3},
+			},
+			wantAssignment: []int{0, 1},
+			wantTotal:      6,
+		},
+		{
+			name: "anti-diagonal",
+			weights: [][]float64{
+				{1, 3},
+				{3, 1},
+			},
+			wantAssignment: []int{1, 0},
+			wantTotal:      6,
+		},
+		{
+			name: "greedy choice is wrong",
+			weights: [][]float64{
+				{7, 6, 0},
+				{6, 0, 0},
+				{0, 0, 1},
+			},
+			wantAssignment: []int{1, 0, 2},
+			wantTotal:      13,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignment, total, slack := maxWeightMatching(tt.weights)
+			if !slices.Equal(assignment, tt.wantAssignment) {
+				t.Errorf("got assignment %v, want %v", assignment, tt.wantAssignment)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("got total %v, want %v", total, tt.wantTotal)
+			}
+			for i, row := range slack {
+				for j, s := range row {
+					if s < 0 || j == assignment[i] && s != 0 {
+						t.Errorf("got slack %v for cell (%d, %d)", s, i, j)
+					}
+				}
+			}
+		})
+	}
+}
+
+// dummyComparison returns a comparison between two resources of the same
+// module, with the given number of matching and mismatching attributes.
+func dummyComparison(createAddress, deleteAddress string, matching, mismatching int) ResourceComparison {
+	c := ResourceComparison{
+		ToCreate: dummyResource("", "", createAddress),
+		ToDelete: dummyResource("", "", deleteAddress),
+	}
+	for i := range matching {
+		c.MatchingAttributes = append(c.MatchingAttributes, string(rune('a'+i)))
+	}
+	for i := range mismatching {
+		c.MismatchingAttributes = append(c.MismatchingAttributes, string(rune('z'-i)))
+	}
+	return c
+}
+
+func dummyMove(fromAddress, toAddress string) Move {
+	return Move{
+		SourceModule:       "dummy_module",
+		SourceAddress:      fromAddress,
+		DestinationModule:  "dummy_module",
+		DestinationAddress: toAddress,
+	}
+}
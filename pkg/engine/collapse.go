@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+)
+
+// CollapseModuleMoves replaces moves of all resources within a module instance
+// with a single move of the module instance itself. This happens when a module
+// call is renamed, or when its instance keys change: every resource within
+// `module.network` moves to the same relative address within `module.vpc`,
+// and one move from `module.network` to `module.vpc` is enough.
+//
+// The plan is used to make sure no resource is left behind: a module instance
+// is only moved when all of its resources are, and when no resource exists at
+// its new address yet. Modules are only collapsed within the same module ID.
+// Moves that cannot be collapsed are returned as is.
+func CollapseModuleMoves(moves []Move, plan Plan) []Move {
+	type prefixPair struct {
+		moduleID string
+		from, to string
+	}
+
+	// Find, for each move, the module instances it could be part of a move
+	// of. The same resource can be nested in several module instances.
+	candidates := make(map[prefixPair][]int)
+	for i, m := range moves {
+		if m.SourceModule != m.DestinationModule {
+			continue
+		}
+
+		for _, from := range modulePrefixes(m.SourceAddress) {
+			rest := strings.TrimPrefix(m.SourceAddress, from)
+			to, ok := strings.CutSuffix(m.DestinationAddress, rest)
+			if !ok || to == from || !isModulePrefix(to, m.DestinationAddress) {
+				continue
+			}
+
+			pair := prefixPair{m.SourceModule, from, to}
+			candidates[pair] = append(candidates[pair], i)
+		}
+	}
+
+	// Outer module instances are collapsed first, so that moves of nested
+	// module instances are only used when the outer instance cannot move as
+	// a whole.
+	pairs := make([]prefixPair, 0, len(candidates))
+	for pair := range candidates {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		a, b := pairs[i], pairs[j]
+		switch {
+		case len(a.from) != len(b.from):
+			return len(a.from) < len(b.from)
+		case a.moduleID != b.moduleID:
+			return a.moduleID < b.moduleID
+		case a.from != b.from:
+			return a.from < b.from
+		default:
+			return a.to < b.to
+		}
+	})
+
+	collapsed := make([]bool, len(moves))
+	var result []Move
+
+	for _, pair := range pairs {
+		indices := candidates[pair]
+		if collapsed[indices[0]] {
+			continue
+		}
+
+		destinations := make(map[string]bool, len(indices))
+		for _, i := range indices {
+			destinations[moves[i].DestinationAddress] = true
+		}
+
+		if !canCollapse(plan, pair.moduleID, pair.from, pair.to, len(indices), destinations) {
+			continue
+		}
+
+		moduleMove := Move{
+			SourceModule:       pair.moduleID,
+			DestinationModule:  pair.moduleID,
+			SourceAddress:      pair.from,
+			DestinationAddress: pair.to,
+		}
+		for _, i := range indices {
+			collapsed[i] = true
+			moduleMove.BestEffort = moduleMove.BestEffort || moves[i].BestEffort
+			moduleMove.Approximate = moduleMove.Approximate || moves[i].Approximate
+		}
+
+		result = append(result, moduleMove)
+	}
+
+	for i, m := range moves {
+		if !collapsed[i] {
+			result = append(result, m)
+		}
+	}
+
+	sortMoves(result)
+
+	return result
+}
+
+// canCollapse checks whether the given number of moves from one module
+// instance to another cover all resources within the instance, and whether
+// the destination instance is free. Resources planned for creation within the
+// destination instance must all be destinations of the moves, and none may be
+// planned within the source instance, which would otherwise still exist.
+func canCollapse(plan Plan, moduleID, from, to string, moveCount int, destinations map[string]bool) bool {
+	deleted := 0
+	for _, r := range plan.ToDelete {
+		if r.ModuleID != moduleID {
+			continue
+		}
+		if isModulePrefix(from, r.Address) {
+			deleted++
+		}
+		if isModulePrefix(to, r.Address) {
+			return false
+		}
+	}
+
+	// Each move covers a distinct resource within the source instance, so
+	// counting them is enough to know that no resource is left behind.
+	if deleted != moveCount {
+		return false
+	}
+
+	for _, r := range plan.ToCreate {
+		if r.ModuleID != moduleID {
+			continue
+		}
+		if isModulePrefix(from, r.Address) {
+			return false
+		}
+		if isModulePrefix(to, r.Address) && !destinations[r.Address] {
+			return false
+		}
+	}
+
+	for _, r := range plan.ToKeep {
+		if r.ModuleID != moduleID {
+			continue
+		}
+		if isModulePrefix(from, r.Address) || isModulePrefix(to, r.Address) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isModulePrefix reports whether the given address is nested within the given
+// module instance.
+func isModulePrefix(prefix, address string) bool {
+	for _, p := range modulePrefixes(address) {
+		if p == prefix {
+			return true
+		}
+	}
+	return false
+}
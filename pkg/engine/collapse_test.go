@@ -0,0 +1,230 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCollapseModuleMoves(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []Move
+		plan  Plan
+		want  []Move
+	}{
+		{
+			name: "renamed module",
+			moves: []Move{
+				dummyMove("module.network.aws_vpc.main", "module.vpc.aws_vpc.main"),
+				dummyMove("module.network.aws_subnet.a", "module.vpc.aws_subnet.a"),
+			},
+			plan: Plan{
+				ToDelete: []Resource{
+					dummyResource("", "", "module.network.aws_vpc.main"),
+					dummyResource("", "", "module.network.aws_subnet.a"),
+				},
+			},
+			want: []Move{
+				dummyMove("module.network", "module.vpc"),
+			},
+		},
+		{
+			name: "changed instance key",
+			moves: []Move{
+				dummyMove(`module.x[0].aws_vpc.main`, `module.x["a"].aws_vpc.main`),
+				dummyMove(`module.x[1].aws_vpc.main`, `module.x["b"].aws_vpc.main`),
+			},
+			plan: Plan{
+				ToDelete: []Resource{
+					dummyResource("", "", `module.x[0].aws_vpc.main`),
+					dummyResource("", "", `module.x[1].aws_vpc.main`),
+				},
+			},
+			want: []Move{
+				dummyMove(`module.x[0]`, `module.x["a"]`),
+				dummyMove(`module.x[1]`, `module.x["b"]`),
+			},
+		},
+		{
+			name: "nested module",
+			moves: []Move{
+				dummyMove("module.a.module.b.aws_vpc.main", "module.a.module.c.aws_vpc.main"),
+			},
+			plan: Plan{
+				ToDelete: []Resource{
+					dummyResource("", "", "module.a.module.b.aws_vpc.main"),
+				},
+				ToKeep: []Resource{
+					dummyResource("", "", "module.a.aws_s3_bucket.logs"),
+				},
+			},
+			want: []Move{
+				dummyMove("module.a.module.b", "module.a.module.c"),
+			},
+		},
+		{
+			name: "resource left behind",
+			moves: []Move{
+				dummyMove("module.network.aws_vpc.main", "module.vpc.aws_vpc.main"),
+			},
+			plan: Plan{
+				ToDelete: []Resource{
+					dummyResource("", "", "module.network.aws_vpc.main"),
+					dummyResource("", "", "module.network.aws_subnet.a"),
+				},
+			},
+			want: []Move{
+				dummyMove("module.network.aws_vpc.main", "module.vpc.aws_vpc.main"),
+			},
+		},
+		{
+			name: "resource kept in source module",
+			moves: []Move{
+				dummyMove("module.network.aws_vpc.main", "module.vpc.aws_vpc.main"),
+			},
+			plan: Plan{
+				ToDelete: []Resource{
+					dummyResource("", "", "module.network.aws_vpc.main"),
+				},
+				ToKeep: []Resource{
+					dummyResource("", "", "module.network.aws_subnet.a"),
+				},
+			},
+			want: []Move{
+				dummyMove("module.network.aws_vpc.main", "module.vpc.aws_vpc.main"),
+			},
+		},
+		{
+			name: "destination module already exists",
+			moves: []Move{
+				dummyMove("module.network.aws_vpc.main", "module.vpc.aws_vpc.main"),
+			},
+			plan: Plan{
+				ToDelete: []Resource{
+					dummyResource("", "", "module.network.aws_vpc.main"),
+				},
+				ToKeep: []Resource{
+					dummyResource("", "", "module.vpc.aws_subnet.a"),
+				},
+			},
+			want: []Move{
+				dummyMove("module.network.aws_vpc.main", "module.vpc.aws_vpc.main"),
+			},
+		},
+		{
+			name: "destinations planned for creation",
+			moves: []Move{
+				dummyMove("module.network.aws_vpc.main", "module.vpc.aws_vpc.main"),
+			},
+			plan: Plan{
+				ToCreate: []Resource{
+					dummyResource("", "", "module.vpc.aws_vpc.main"),
+				},
+				ToDelete: []Resource{
+					dummyResource("", "", "module.network.aws_vpc.main"),
+				},
+			},
+			want: []Move{
+				dummyMove("module.network", "module.vpc"),
+			},
+		},
+		{
+			name: "other resource created in destination module",
+			moves: []Move{
+				dummyMove("module.network.aws_vpc.main", "module.vpc.aws_vpc.main"),
+			},
+			plan: Plan{
+				ToCreate: []Resource{
+					dummyResource("", "", "module.vpc.aws_vpc.main"),
+					dummyResource("", "", "module.vpc.aws_subnet.a"),
+				},
+				ToDelete: []Resource{
+					dummyResource("", "", "module.network.aws_vpc.main"),
+				},
+			},
+			want: []Move{
+				dummyMove("module.network.aws_vpc.main", "module.vpc.aws_vpc.main"),
+			},
+		},
+		{
+			name: "resource created in source module",
+			moves: []Move{
+				dummyMove(`module.x[0].aws_vpc.main`, `module.x[1].aws_vpc.main`),
+			},
+			plan: Plan{
+				ToCreate: []Resource{
+					dummyResource("", "", `module.x[1].aws_vpc.main`),
+					dummyResource("", "", `module.x[0].aws_subnet.a`),
+				},
+				ToDelete: []Resource{
+					dummyResource("", "", `module.x[0].aws_vpc.main`),
+				},
+			},
+			want: []Move{
+				dummyMove(`module.x[0].aws_vpc.main`, `module.x[1].aws_vpc.main`),
+			},
+		},
+		{
+			name: "different relative addresses",
+			moves: []Move{
+				dummyMove("module.network.aws_vpc.main", "module.vpc.aws_vpc.this"),
+			},
+			plan: Plan{
+				ToDelete: []Resource{
+					dummyResource("", "", "module.network.aws_vpc.main"),
+				},
+			},
+			want: []Move{
+				dummyMove("module.network.aws_vpc.main", "module.vpc.aws_vpc.this"),
+			},
+		},
+		{
+			name: "moved out of module",
+			moves: []Move{
+				dummyMove("module.network.aws_vpc.main", "aws_vpc.main"),
+			},
+			plan: Plan{
+				ToDelete: []Resource{
+					dummyResource("", "", "module.network.aws_vpc.main"),
+				},
+			},
+			want: []Move{
+				dummyMove("module.network.aws_vpc.main", "aws_vpc.main"),
+			},
+		},
+		{
+			name: "across modules",
+			moves: []Move{
+				{
+					SourceModule:       "this_module",
+					DestinationModule:  "that_module",
+					SourceAddress:      "module.network.aws_vpc.main",
+					DestinationAddress: "module.vpc.aws_vpc.main",
+				},
+			},
+			plan: Plan{
+				ToDelete: []Resource{
+					{ModuleID: "this_module", Address: "module.network.aws_vpc.main"},
+				},
+			},
+			want: []Move{
+				{
+					SourceModule:       "this_module",
+					DestinationModule:  "that_module",
+					SourceAddress:      "module.network.aws_vpc.main",
+					DestinationAddress: "module.vpc.aws_vpc.main",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CollapseModuleMoves(tt.moves, tt.plan)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
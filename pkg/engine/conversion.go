@@ -0,0 +1,260 @@
+package engine
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Conversion groups the moves of a resource's instances from numeric keys to
+// string keys. This is what converting a resource from count to for_each
+// looks like: `aws_subnet.this[0]` moves to `aws_subnet.this["a"]`, and so on.
+type Conversion struct {
+	// The module the resource is in.
+	ModuleID string
+	// The address of the resource, without any instance key.
+	Address string
+
+	// The moves of the resource's instances, sorted by their numeric key.
+	Moves []Move
+}
+
+// Conversions finds the moves that are part of a count to for_each conversion
+// and groups them by resource. Moves that are not part of a conversion are
+// left out.
+func Conversions(moves []Move) []Conversion {
+	type resourceKey struct {
+		moduleID, address string
+	}
+
+	var order []resourceKey
+	byResource := make(map[resourceKey][]Move)
+
+	for _, m := range moves {
+		if m.SourceModule != m.DestinationModule {
+			continue
+		}
+
+		from, fromKey := InstanceKey(m.SourceAddress)
+		to, toKey := InstanceKey(m.DestinationAddress)
+		if from != to || !isCountKey(fromKey) || !isForEachKey(toKey) {
+			continue
+		}
+
+		rk := resourceKey{m.SourceModule, from}
+		if _, ok := byResource[rk]; !ok {
+			order = append(order, rk)
+		}
+		byResource[rk] = append(byResource[rk], m)
+	}
+
+	var conversions []Conversion
+	for _, rk := range order {
+		moves := byResource[rk]
+		sort.SliceStable(moves, func(i, j int) bool {
+			_, a := InstanceKey(moves[i].SourceAddress)
+			_, b := InstanceKey(moves[j].SourceAddress)
+			ai, _ := strconv.Atoi(a)
+			bi, _ := strconv.Atoi(b)
+			return ai < bi
+		})
+
+		conversions = append(conversions, Conversion{
+			ModuleID: rk.moduleID,
+			Address:  rk.address,
+			Moves:    moves,
+		})
+	}
+
+	return conversions
+}
+
+// keyedMoves pairs the instances of resources converted from count to
+// for_each that the given moves do not already cover. Instances are paired
+// when one of the attributes of the instance Terraform plans to delete gives
+// away the key of the instance Terraform plans to create: an instance with
+// availability_zone "eu-west-1a" was probably converted to key "eu-west-1a",
+// and one with name "subnet-public" to key "public".
+//
+// The instances paired this way do not match, so their moves are marked as
+// approximate.
+func keyedMoves(comparisons []ResourceComparison, moves []Move) []Move {
+	moved := make(map[string]bool)
+	for _, m := range moves {
+		moved["-"+Resource{ModuleID: m.SourceModule, Address: m.SourceAddress}.ID()] = true
+		moved["+"+Resource{ModuleID: m.DestinationModule, Address: m.DestinationAddress}.ID()] = true
+	}
+
+	// Group the instances not yet moved by the resource they belong to.
+	type group struct {
+		toCreate, toDelete []Resource
+		seen               map[string]bool
+	}
+	var order []string
+	groups := make(map[string]*group)
+
+	add := func(r Resource, toCreate bool) {
+		if toCreate && moved["+"+r.ID()] || !toCreate && moved["-"+r.ID()] {
+			return
+		}
+
+		address, key := InstanceKey(r.Address)
+		if toCreate && !isForEachKey(key) || !toCreate && !isCountKey(key) {
+			return
+		}
+
+		id := Resource{ModuleID: r.ModuleID, Address: address}.ID()
+		g, ok := groups[id]
+		if !ok {
+			g = &group{seen: make(map[string]bool)}
+			groups[id] = g
+			order = append(order, id)
+		}
+
+		if g.seen[r.ID()] {
+			return
+		}
+		g.seen[r.ID()] = true
+
+		if toCreate {
+			g.toCreate = append(g.toCreate, r)
+		} else {
+			g.toDelete = append(g.toDelete, r)
+		}
+	}
+
+	for _, c := range comparisons {
+		// Only instances of the same resource can be part of a conversion.
+		createAddr, _ := InstanceKey(c.ToCreate.Address)
+		deleteAddr, _ := InstanceKey(c.ToDelete.Address)
+		if c.ToCreate.ModuleID != c.ToDelete.ModuleID || createAddr != deleteAddr {
+			continue
+		}
+
+		add(c.ToCreate, true)
+		add(c.ToDelete, false)
+	}
+
+	var keyed []Move
+	for _, id := range order {
+		g := groups[id]
+		keyed = append(keyed, pairByKey(g.toCreate, g.toDelete)...)
+	}
+	for i := range keyed {
+		keyed[i].Approximate = true
+	}
+
+	return keyed
+}
+
+// pairByKey pairs instances based on the attribute that best predicts the keys
+// of the instances to create.
+func pairByKey(toCreate, toDelete []Resource) []Move {
+	if len(toCreate) == 0 || len(toDelete) == 0 {
+		return nil
+	}
+
+	// Consider every attribute with a string value in at least one of the
+	// instances to delete. Identifiers are assigned by the provider, so they
+	// have nothing to do with the keys chosen by the user.
+	var attributes []string
+	seen := make(map[string]bool)
+	for _, d := range toDelete {
+		for attr, v := range d.Attributes {
+			if isIdentifier(attr) {
+				continue
+			}
+			if _, ok := v.(string); ok && !seen[attr] {
+				seen[attr] = true
+				attributes = append(attributes, attr)
+			}
+		}
+	}
+	sort.Strings(attributes)
+
+	var best []Move
+	for _, attr := range attributes {
+		pairs := pairByAttribute(toCreate, toDelete, attr)
+		if len(pairs) > len(best) {
+			best = pairs
+		}
+	}
+
+	return best
+}
+
+// minSuffixKeyLength is how long a key must be for pairByAttribute to pair
+// it with values that end with it. Shorter keys, like "a", are the suffix of
+// too many unrelated values.
+const minSuffixKeyLength = 3
+
+// pairByAttribute pairs each instance to create with the only instance to
+// delete whose attribute is equal to the instance's key or, for keys that are
+// not too short, ends with it. Instances that could be paired several ways are
+// left alone.
+func pairByAttribute(toCreate, toDelete []Resource, attr string) []Move {
+	var moves []Move
+	used := make(map[int]int)
+
+	for _, c := range toCreate {
+		_, rawKey := InstanceKey(c.Address)
+		key, _ := strconv.Unquote(rawKey)
+		if key == "" {
+			continue
+		}
+
+		candidates := matchingInstances(toDelete, attr, func(v string) bool { return v == key })
+		if len(candidates) == 0 && len(key) >= minSuffixKeyLength {
+			candidates = matchingInstances(toDelete, attr, func(v string) bool { return strings.HasSuffix(v, key) })
+		}
+		if len(candidates) != 1 {
+			continue
+		}
+
+		d := candidates[0]
+		used[d]++
+
+		m := moveFromComparison(ResourceComparison{ToCreate: c, ToDelete: toDelete[d]})
+		m.KeyAttribute = attr
+		moves = append(moves, m)
+	}
+
+	// Two instances to create cannot be paired with the same instance to
+	// delete.
+	var unique []Move
+	for _, m := range moves {
+		for d, r := range toDelete {
+			if r.Address == m.SourceAddress && used[d] == 1 {
+				unique = append(unique, m)
+			}
+		}
+	}
+
+	return unique
+}
+
+func matchingInstances(resources []Resource, attr string, match func(string) bool) []int {
+	var indices []int
+	for i, r := range resources {
+		if v, ok := r.Attributes[attr].(string); ok && match(v) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// isIdentifier reports whether the attribute holds an identifier assigned by
+// the provider, like "id", "arn" or "vpc_id".
+func isIdentifier(attr string) bool {
+	name := attr[strings.LastIndex(attr, ".")+1:]
+	return name == "id" || name == "arn" || strings.HasSuffix(name, "_id") || strings.HasSuffix(name, "_arn")
+}
+
+func isCountKey(key string) bool {
+	_, err := strconv.Atoi(key)
+	return err == nil
+}
+
+func isForEachKey(key string) bool {
+	return strings.HasPrefix(key, `"`)
+}
@@ -0,0 +1,209 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDetermineMovesWithKeyHeuristics(t *testing.T) {
+	subnet := func(address, az, name string) Resource {
+		return Resource{
+			ModuleID: "dummy_module",
+			Type:     "aws_subnet",
+			Address:  address,
+			Attributes: map[string]any{
+				"availability_zone": az,
+				"name":              name,
+				"vpc_id":            "vpc-1234",
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		create []Resource
+		delete []Resource
+		want   []Move
+	}{
+		{
+			name: "key equal to attribute",
+			create: []Resource{
+				subnet(`aws_subnet.this["eu-west-1a"]`, "eu-west-1a", "subnet-eu-west-1a"),
+				subnet(`aws_subnet.this["eu-west-1b"]`, "eu-west-1b", "subnet-eu-west-1b"),
+			},
+			delete: []Resource{
+				subnet(`aws_subnet.this[0]`, "eu-west-1a", "subnet-0"),
+				subnet(`aws_subnet.this[1]`, "eu-west-1b", "subnet-1"),
+			},
+			want: []Move{
+				{
+					SourceModule:       "dummy_module",
+					DestinationModule:  "dummy_module",
+					SourceAddress:      `aws_subnet.this[0]`,
+					DestinationAddress: `aws_subnet.this["eu-west-1a"]`,
+					KeyAttribute:       "availability_zone",
+					Approximate:        true,
+				},
+				{
+					SourceModule:       "dummy_module",
+					DestinationModule:  "dummy_module",
+					SourceAddress:      `aws_subnet.this[1]`,
+					DestinationAddress: `aws_subnet.this["eu-west-1b"]`,
+					KeyAttribute:       "availability_zone",
+					Approximate:        true,
+				},
+			},
+		},
+		{
+			name: "key suffix of attribute",
+			create: []Resource{
+				subnet(`aws_subnet.this["public"]`, "eu-west-1a", "public"),
+				subnet(`aws_subnet.this["private"]`, "eu-west-1a", "private"),
+			},
+			delete: []Resource{
+				subnet(`aws_subnet.this[0]`, "eu-west-1a", "main-public"),
+				subnet(`aws_subnet.this[1]`, "eu-west-1a", "main-private"),
+			},
+			want: []Move{
+				{
+					SourceModule:       "dummy_module",
+					DestinationModule:  "dummy_module",
+					SourceAddress:      `aws_subnet.this[0]`,
+					DestinationAddress: `aws_subnet.this["public"]`,
+					KeyAttribute:       "name",
+					Approximate:        true,
+				},
+				{
+					SourceModule:       "dummy_module",
+					DestinationModule:  "dummy_module",
+					SourceAddress:      `aws_subnet.this[1]`,
+					DestinationAddress: `aws_subnet.this["private"]`,
+					KeyAttribute:       "name",
+					Approximate:        true,
+				},
+			},
+		},
+		{
+			name: "short key suffix of attribute",
+			create: []Resource{
+				subnet(`aws_subnet.this["a"]`, "eu-west-1a", "subnet-a"),
+			},
+			delete: []Resource{
+				subnet(`aws_subnet.this[0]`, "eu-west-1a", "subnet-0"),
+			},
+			want: nil,
+		},
+		{
+			name: "no matching attributes",
+			create: []Resource{
+				{
+					ModuleID:   "dummy_module",
+					Type:       "aws_subnet",
+					Address:    `aws_subnet.this["09f3a"]`,
+					Attributes: map[string]any{"id": nil, "cidr_block": "10.0.1.0/24"},
+				},
+			},
+			delete: []Resource{
+				{
+					ModuleID:   "dummy_module",
+					Type:       "aws_subnet",
+					Address:    `aws_subnet.this[0]`,
+					Attributes: map[string]any{"id": "subnet-09f3a", "arn": "arn:aws:ec2:subnet/subnet-09f3a", "cidr_block": "10.0.0.0/24"},
+				},
+			},
+			want: nil,
+		},
+		{
+			name: "exact matches come first",
+			create: []Resource{
+				subnet(`aws_subnet.this["eu-west-1a"]`, "eu-west-1a", "subnet-0"),
+				subnet(`aws_subnet.this["eu-west-1b"]`, "eu-west-1b", "subnet-b"),
+			},
+			delete: []Resource{
+				subnet(`aws_subnet.this[0]`, "eu-west-1a", "subnet-0"),
+				subnet(`aws_subnet.this[1]`, "eu-west-1b", "subnet-1"),
+			},
+			want: []Move{
+				{
+					SourceModule:       "dummy_module",
+					DestinationModule:  "dummy_module",
+					SourceAddress:      `aws_subnet.this[0]`,
+					DestinationAddress: `aws_subnet.this["eu-west-1a"]`,
+				},
+				{
+					SourceModule:       "dummy_module",
+					DestinationModule:  "dummy_module",
+					SourceAddress:      `aws_subnet.this[1]`,
+					DestinationAddress: `aws_subnet.this["eu-west-1b"]`,
+					KeyAttribute:       "availability_zone",
+					Approximate:        true,
+				},
+			},
+		},
+		{
+			name: "ambiguous keys",
+			create: []Resource{
+				subnet(`aws_subnet.this["eu-west-1a"]`, "eu-west-1a", "subnet-a"),
+			},
+			delete: []Resource{
+				subnet(`aws_subnet.this[0]`, "eu-west-1a", "subnet-0"),
+				subnet(`aws_subnet.this[1]`, "eu-west-1a", "subnet-1"),
+			},
+			want: nil,
+		},
+		{
+			name: "different resources",
+			create: []Resource{
+				subnet(`aws_subnet.that["eu-west-1a"]`, "eu-west-1a", "subnet-a"),
+			},
+			delete: []Resource{
+				subnet(`aws_subnet.this[0]`, "eu-west-1a", "subnet-0"),
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparisons := CompareAll(Plan{ToCreate: tt.create, ToDelete: tt.delete}, nil)
+
+			got := DetermineMoves(comparisons, WithKeyHeuristics(true))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConversions(t *testing.T) {
+	moves := []Move{
+		dummyMove(`aws_subnet.this[10]`, `aws_subnet.this["c"]`),
+		dummyMove(`aws_subnet.this[2]`, `aws_subnet.this["b"]`),
+		dummyMove(`aws_subnet.other`, `aws_subnet.renamed`),
+		dummyMove(`aws_subnet.this[0]`, `aws_subnet.that["a"]`),
+		dummyMove(`module.a[0].aws_instance.web[0]`, `module.a[0].aws_instance.web["x"]`),
+	}
+
+	want := []Conversion{
+		{
+			ModuleID: "dummy_module",
+			Address:  "aws_subnet.this",
+			Moves: []Move{
+				dummyMove(`aws_subnet.this[2]`, `aws_subnet.this["b"]`),
+				dummyMove(`aws_subnet.this[10]`, `aws_subnet.this["c"]`),
+			},
+		},
+		{
+			ModuleID: "dummy_module",
+			Address:  "module.a[0].aws_instance.web",
+			Moves: []Move{
+				dummyMove(`module.a[0].aws_instance.web[0]`, `module.a[0].aws_instance.web["x"]`),
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, Conversions(moves)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,325 @@
+package engine
+
+import (
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Dependencies describes which resources depend on which other resources,
+// before and after the planned changes. Resources are identified by their
+// module's ID and their configuration address, which is their address without
+// instance keys. For example, `aws_instance.web[0]` in module `prod` is
+// identified as `prod:aws_instance.web`.
+//
+// Dependencies allow the engine to tell apart resources that are otherwise
+// identical: if two resources are the same, then the resources they depend on
+// must be the same too, and vice versa.
+type Dependencies struct {
+	// Before maps each resource in the prior state to the resources it
+	// depended on.
+	Before map[string][]string
+
+	// After maps each resource in the configuration to the resources it
+	// references.
+	After map[string][]string
+}
+
+func dependencyKey(moduleID, address string) string {
+	return moduleID + ":" + ConfigAddress(address)
+}
+
+func (d Dependencies) isEmpty() bool {
+	return len(d.Before) == 0 && len(d.After) == 0
+}
+
+func mergeDependencies(all []Dependencies) Dependencies {
+	merged := Dependencies{
+		Before: make(map[string][]string),
+		After:  make(map[string][]string),
+	}
+
+	for _, d := range all {
+		for k, v := range d.Before {
+			merged.Before[k] = append(merged.Before[k], v...)
+		}
+		for k, v := range d.After {
+			merged.After[k] = append(merged.After[k], v...)
+		}
+	}
+
+	return merged
+}
+
+// dependenciesFromJSONPlan reads dependencies from the prior state and the
+// configuration embedded in a Terraform plan.
+func dependenciesFromJSONPlan(moduleID string, jsonPlan *tfjson.Plan) Dependencies {
+	deps := Dependencies{
+		Before: make(map[string][]string),
+		After:  make(map[string][]string),
+	}
+
+	if jsonPlan.PriorState != nil && jsonPlan.PriorState.Values != nil {
+		addStateDependencies(deps.Before, moduleID, jsonPlan.PriorState.Values.RootModule)
+	}
+
+	if jsonPlan.Config != nil {
+		addConfigDependencies(deps.After, moduleID, "", jsonPlan.Config.RootModule)
+	}
+
+	return deps
+}
+
+func addStateDependencies(before map[string][]string, moduleID string, module *tfjson.StateModule) {
+	if module == nil {
+		return
+	}
+
+	for _, r := range module.Resources {
+		key := dependencyKey(moduleID, r.Address)
+
+		// The key must exist even without dependencies, so that we know the
+		// resource existed.
+		if _, ok := before[key]; !ok {
+			before[key] = nil
+		}
+
+		// In the state, dependencies are absolute configuration addresses.
+		for _, dep := range r.DependsOn {
+			before[key] = appendUnique(before[key], dependencyKey(moduleID, dep))
+		}
+	}
+
+	for _, child := range module.ChildModules {
+		addStateDependencies(before, moduleID, child)
+	}
+}
+
+func addConfigDependencies(after map[string][]string, moduleID, prefix string, module *tfjson.ConfigModule) {
+	if module == nil {
+		return
+	}
+
+	for _, r := range module.Resources {
+		key := dependencyKey(moduleID, prefix+r.Address)
+
+		if _, ok := after[key]; !ok {
+			after[key] = nil
+		}
+
+		// In the configuration, references are relative to the module the
+		// resource is declared in.
+		refs := append([]string(nil), r.DependsOn...)
+		refs = append(refs, expressionReferences(r.CountExpression)...)
+		refs = append(refs, expressionReferences(r.ForEachExpression)...)
+		for _, e := range r.Expressions {
+			refs = append(refs, expressionReferences(e)...)
+		}
+
+		for _, ref := range refs {
+			addr := resourceReference(ref)
+			if addr == "" {
+				continue
+			}
+			after[key] = appendUnique(after[key], dependencyKey(moduleID, prefix+addr))
+		}
+	}
+
+	for name, call := range module.ModuleCalls {
+		addConfigDependencies(after, moduleID, prefix+"module."+name+".", call.Module)
+	}
+}
+
+func expressionReferences(e *tfjson.Expression) []string {
+	if e == nil || e.ExpressionData == nil {
+		return nil
+	}
+
+	refs := append([]string(nil), e.References...)
+	for _, block := range e.NestedBlocks {
+		for _, nested := range block {
+			refs = append(refs, expressionReferences(nested)...)
+		}
+	}
+
+	return refs
+}
+
+// resourceReference returns the configuration address of the resource the
+// given reference points to, or an empty string if the reference does not
+// point to a resource. For example, `aws_instance.web[0].id` becomes
+// `aws_instance.web`.
+func resourceReference(ref string) string {
+	parts := strings.Split(ConfigAddress(ref), ".")
+
+	switch parts[0] {
+	case "var", "local", "each", "count", "path", "terraform", "self", "module":
+		return ""
+	case "data":
+		if len(parts) < 3 {
+			return ""
+		}
+		return strings.Join(parts[:3], ".")
+	default:
+		if len(parts) < 2 {
+			return ""
+		}
+		return strings.Join(parts[:2], ".")
+	}
+}
+
+func appendUnique(s []string, e string) []string {
+	for _, existing := range s {
+		if existing == e {
+			return s
+		}
+	}
+	return append(s, e)
+}
+
+// resolveWithDependencies moves resources that the given moves alone could
+// not resolve, by comparing what resources depend on before and after the
+// planned changes. Each new move provides more information, so we repeat the
+// process until no new moves are found.
+func resolveWithDependencies(candidates []ResourceComparison, moves []Move, deps Dependencies, strategy Assignment) []Move {
+	for {
+		c := inferCorrespondences(deps, moves)
+
+		var consistent []ResourceComparison
+		for _, comparison := range candidates {
+			if c.allows(comparison) {
+				consistent = append(consistent, comparison)
+			}
+		}
+
+		next := assign(consistent, strategy)
+		if len(next) <= len(moves) {
+			return moves
+		}
+
+		moves = next
+	}
+}
+
+// correspondences records which resource in the configuration is known to be
+// which resource in the prior state.
+type correspondences struct {
+	newToOld map[string]string
+	oldToNew map[string]string
+
+	// Resources for which we found contradicting evidence. We know nothing
+	// about them.
+	conflicts map[string]bool
+}
+
+func (c correspondences) record(newKey, oldKey string) {
+	if o, ok := c.newToOld[newKey]; ok && o != oldKey {
+		c.conflicts["+"+newKey] = true
+	}
+	if n, ok := c.oldToNew[oldKey]; ok && n != newKey {
+		c.conflicts["-"+oldKey] = true
+	}
+
+	c.newToOld[newKey] = oldKey
+	c.oldToNew[oldKey] = newKey
+}
+
+// allows returns whether the comparison's resources could be the same
+// resource, given what we know.
+func (c correspondences) allows(comparison ResourceComparison) bool {
+	newKey := dependencyKey(comparison.ToCreate.ModuleID, comparison.ToCreate.Address)
+	oldKey := dependencyKey(comparison.ToDelete.ModuleID, comparison.ToDelete.Address)
+
+	if o, ok := c.newToOld[newKey]; ok && !c.conflicts["+"+newKey] && o != oldKey {
+		return false
+	}
+	if n, ok := c.oldToNew[oldKey]; ok && !c.conflicts["-"+oldKey] && n != newKey {
+		return false
+	}
+
+	return true
+}
+
+// inferCorrespondences starts from resources we know are the same before and
+// after the planned changes, and deduces which of their dependencies and
+// dependents are the same too.
+//
+// We know a resource is the same if it keeps its address or if it is moved.
+// When such a resource depends on exactly one resource of a given type, both
+// before and after the changes, those two resources must be the same. The
+// same goes for resources that depend on it.
+func inferCorrespondences(deps Dependencies, moves []Move) correspondences {
+	c := correspondences{
+		newToOld:  make(map[string]string),
+		oldToNew:  make(map[string]string),
+		conflicts: make(map[string]bool),
+	}
+
+	type anchor struct{ newKey, oldKey string }
+	var anchors []anchor
+
+	moved := make(map[string]bool)
+	for _, m := range moves {
+		newKey := dependencyKey(m.DestinationModule, m.DestinationAddress)
+		oldKey := dependencyKey(m.SourceModule, m.SourceAddress)
+		anchors = append(anchors, anchor{newKey, oldKey})
+		moved["+"+newKey] = true
+		moved["-"+oldKey] = true
+	}
+
+	for key := range deps.After {
+		if _, ok := deps.Before[key]; !ok {
+			continue
+		}
+		if moved["+"+key] || moved["-"+key] {
+			continue
+		}
+		anchors = append(anchors, anchor{key, key})
+	}
+
+	dependentsBefore := invert(deps.Before)
+	dependentsAfter := invert(deps.After)
+
+	for _, a := range anchors {
+		relateByType(c, deps.After[a.newKey], deps.Before[a.oldKey])
+		relateByType(c, dependentsAfter[a.newKey], dependentsBefore[a.oldKey])
+	}
+
+	return c
+}
+
+// relateByType records a correspondence between two resources when they are
+// the only resources of their type in their respective lists.
+func relateByType(c correspondences, newKeys, oldKeys []string) {
+	newByType := groupByType(newKeys)
+	oldByType := groupByType(oldKeys)
+
+	for typ, news := range newByType {
+		olds := oldByType[typ]
+		if len(news) == 1 && len(olds) == 1 {
+			c.record(news[0], olds[0])
+		}
+	}
+}
+
+func groupByType(keys []string) map[string][]string {
+	byType := make(map[string][]string)
+	for _, k := range keys {
+		// Module IDs are often paths, which may contain colons. Addresses
+		// never do.
+		address := k[strings.LastIndex(k, ":")+1:]
+		typ := resourceTypeOf(address)
+		byType[typ] = appendUnique(byType[typ], k)
+	}
+	return byType
+}
+
+func invert(graph map[string][]string) map[string][]string {
+	inverted := make(map[string][]string)
+	for from, tos := range graph {
+		for _, to := range tos {
+			inverted[to] = appendUnique(inverted[to], from)
+		}
+	}
+	return inverted
+}
@@ -0,0 +1,205 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestDetermineMovesWithDependencies(t *testing.T) {
+	// Two random_integer resources are renamed. They have identical attributes,
+	// so each matches both of the other's candidates. The random_pet resources
+	// that reference them keep their addresses, and tell us which is which.
+	integer := func(address string) Resource {
+		return Resource{
+			ModuleID:   "workdir",
+			Type:       "random_integer",
+			Address:    address,
+			Attributes: map[string]any{"min": 1, "max": 5},
+		}
+	}
+
+	comparisons := []ResourceComparison{
+		{ToCreate: integer("random_integer.alpha"), ToDelete: integer("random_integer.first")},
+		{ToCreate: integer("random_integer.alpha"), ToDelete: integer("random_integer.second")},
+		{ToCreate: integer("random_integer.beta"), ToDelete: integer("random_integer.first")},
+		{ToCreate: integer("random_integer.beta"), ToDelete: integer("random_integer.second")},
+	}
+
+	deps := Dependencies{
+		Before: map[string][]string{
+			"workdir:random_integer.first":  nil,
+			"workdir:random_integer.second": nil,
+			"workdir:random_pet.first":      {"workdir:random_integer.first"},
+			"workdir:random_pet.second":     {"workdir:random_integer.second"},
+		},
+		After: map[string][]string{
+			"workdir:random_integer.alpha": nil,
+			"workdir:random_integer.beta":  nil,
+			"workdir:random_pet.first":     {"workdir:random_integer.alpha"},
+			"workdir:random_pet.second":    {"workdir:random_integer.beta"},
+		},
+	}
+
+	move := func(from, to string) Move {
+		return Move{
+			SourceModule:       "workdir",
+			SourceAddress:      from,
+			DestinationModule:  "workdir",
+			DestinationAddress: to,
+		}
+	}
+
+	t.Run("without dependencies", func(t *testing.T) {
+		got := DetermineMoves(comparisons)
+		if len(got) != 0 {
+			t.Errorf("got %v, want no moves", got)
+		}
+	})
+
+	t.Run("with dependencies", func(t *testing.T) {
+		want := []Move{
+			move("random_integer.first", "random_integer.alpha"),
+			move("random_integer.second", "random_integer.beta"),
+		}
+
+		got := DetermineMoves(comparisons, WithDependencies(deps))
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("with dependents", func(t *testing.T) {
+		// This time, the random_integer resources reference the random_pet
+		// resources instead of the other way around.
+		reversed := Dependencies{
+			Before: map[string][]string{
+				"workdir:random_integer.first":  {"workdir:random_pet.first"},
+				"workdir:random_integer.second": {"workdir:random_pet.second"},
+				"workdir:random_pet.first":      nil,
+				"workdir:random_pet.second":     nil,
+			},
+			After: map[string][]string{
+				"workdir:random_integer.alpha": {"workdir:random_pet.first"},
+				"workdir:random_integer.beta":  {"workdir:random_pet.second"},
+				"workdir:random_pet.first":     nil,
+				"workdir:random_pet.second":    nil,
+			},
+		}
+
+		want := []Move{
+			move("random_integer.first", "random_integer.alpha"),
+			move("random_integer.second", "random_integer.beta"),
+		}
+
+		got := DetermineMoves(comparisons, WithDependencies(reversed))
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("with ambiguous dependencies", func(t *testing.T) {
+		// Both random_pet resources depend on both random_integer resources,
+		// which tells us nothing.
+		ambiguous := Dependencies{
+			Before: map[string][]string{
+				"workdir:random_pet.first":  {"workdir:random_integer.first", "workdir:random_integer.second"},
+				"workdir:random_pet.second": {"workdir:random_integer.first", "workdir:random_integer.second"},
+			},
+			After: map[string][]string{
+				"workdir:random_pet.first":  {"workdir:random_integer.alpha", "workdir:random_integer.beta"},
+				"workdir:random_pet.second": {"workdir:random_integer.alpha", "workdir:random_integer.beta"},
+			},
+		}
+
+		got := DetermineMoves(comparisons, WithDependencies(ambiguous))
+		if len(got) != 0 {
+			t.Errorf("got %v, want no moves", got)
+		}
+	})
+}
+
+func TestDependenciesFromJSONPlan(t *testing.T) {
+	jsonPlan := &tfjson.Plan{
+		PriorState: &tfjson.State{
+			Values: &tfjson.StateValues{
+				RootModule: &tfjson.StateModule{
+					Resources: []*tfjson.StateResource{
+						{Address: "random_integer.first"},
+						{Address: "random_pet.first", DependsOn: []string{"random_integer.first"}},
+					},
+					ChildModules: []*tfjson.StateModule{
+						{
+							Address: "module.child[0]",
+							Resources: []*tfjson.StateResource{
+								{
+									Address:   "module.child[0].random_pet.this[\"a\"]",
+									DependsOn: []string{"module.child.random_integer.this"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Config: &tfjson.Config{
+			RootModule: &tfjson.ConfigModule{
+				Resources: []*tfjson.ConfigResource{
+					{Address: "random_integer.alpha"},
+					{
+						Address: "random_pet.first",
+						Expressions: map[string]*tfjson.Expression{
+							"length": {ExpressionData: &tfjson.ExpressionData{
+								References: []string{"random_integer.alpha.result", "random_integer.alpha"},
+							}},
+							"prefix": {ExpressionData: &tfjson.ExpressionData{
+								References: []string{"var.prefix", "data.external.prefix.result"},
+							}},
+						},
+					},
+				},
+				ModuleCalls: map[string]*tfjson.ModuleCall{
+					"child": {
+						Module: &tfjson.ConfigModule{
+							Resources: []*tfjson.ConfigResource{
+								{
+									Address:   "random_pet.this",
+									DependsOn: []string{"random_integer.this"},
+									ForEachExpression: &tfjson.Expression{ExpressionData: &tfjson.ExpressionData{
+										References: []string{"local.keys"},
+									}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	want := Dependencies{
+		Before: map[string][]string{
+			"dir:random_integer.first":         nil,
+			"dir:random_pet.first":             {"dir:random_integer.first"},
+			"dir:module.child.random_pet.this": {"dir:module.child.random_integer.this"},
+		},
+		After: map[string][]string{
+			"dir:random_integer.alpha":         nil,
+			"dir:random_pet.first":             {"dir:random_integer.alpha", "dir:data.external.prefix"},
+			"dir:module.child.random_pet.this": {"dir:module.child.random_integer.this"},
+		},
+	}
+
+	got := dependenciesFromJSONPlan("dir", jsonPlan)
+
+	sortDependencies := cmp.Transformer("sort", func(in []string) []string {
+		out := slices.Clone(in)
+		slices.Sort(out)
+		return out
+	})
+	if diff := cmp.Diff(want, got, sortDependencies); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -16,51 +16,105 @@ type Move struct {
 	SourceAddress string
 	// The resource's address after the move.
 	DestinationAddress string
-}
 
-func DetermineMoves(comparisons []ResourceComparison) []Move {
+	// Whether the move was picked among other, equally good pairings of the
+	// resources involved. See AssignmentBestEffort.
+	BestEffort bool
+
+	// Whether the resources involved do not match, but are similar enough to
+	// be paired anyway. See WithMinSimilarity.
+	Approximate bool
 
-	// We choose to move a resource planned for deletion to a resource planned
-	// for creation if and only if the resources match each other and
-	// only each other.
+	// The attribute the resources were paired by, based on the instance key of
+	// the resource to create. Empty unless the resources were paired as part
+	// of a count to for_each conversion. See WithKeyHeuristics.
+	KeyAttribute string
+}
 
-	planToCreateMatchCount := make(map[string]int)
-	planToDeleteMatchCount := make(map[string]int)
-	for _, comparison := range comparisons {
-		if comparison.IsMatch() {
-			planToCreateMatchCount[comparison.ToCreate.ID()]++
-			planToDeleteMatchCount[comparison.ToDelete.ID()]++
+// DetermineMoves decides which moves to make based on the given comparisons.
+//
+// By default, we choose to move a resource planned for deletion to a resource
+// planned for creation if and only if the resources match each other and only
+// each other. Options can change how resources with several matches are
+// handled, use dependencies between resources to tell them apart, pair the
+// instances of resources converted from count to for_each, and allow
+// resources that do not match to be moved if they are similar enough.
+func DetermineMoves(comparisons []ResourceComparison, opts ...Option) []Move {
+	var settings settings
+	settings.apply(append(defaultOptions(), opts...))
+
+	var matches []ResourceComparison
+	for _, c := range comparisons {
+		if c.IsMatch() {
+			matches = append(matches, c)
 		}
 	}
 
-	var moves []Move
+	moves := assign(matches, settings.assignment)
 
-	for _, comparison := range comparisons {
-		if !comparison.IsMatch() {
-			continue
+	if !settings.dependencies.isEmpty() {
+		moves = resolveWithDependencies(matches, moves, settings.dependencies, settings.assignment)
+	}
+
+	if settings.keyHeuristics {
+		moves = append(moves, keyedMoves(comparisons, moves)...)
+	}
+
+	if settings.minSimilarity > 0 {
+		moves = append(moves, approximateMoves(comparisons, moves, settings)...)
+	}
+
+	// Sort the moves so that the result is deterministic.
+	sortMoves(moves)
+
+	return moves
+}
+
+// approximateMoves pairs resources that have no match at all, based on
+// comparisons that are similar enough to the user's liking. Resources the
+// given moves already cover are left alone.
+func approximateMoves(comparisons []ResourceComparison, moves []Move, settings settings) []Move {
+	// Resources with at least one match are left alone, even if that match
+	// did not lead to a move. Guessing a less similar pairing for them would
+	// be wrong more often than not.
+	hasMatch := make(map[string]bool)
+	for _, c := range comparisons {
+		if c.IsMatch() {
+			hasMatch["+"+c.ToCreate.ID()] = true
+			hasMatch["-"+c.ToDelete.ID()] = true
 		}
+	}
+	for _, m := range moves {
+		hasMatch["+"+Resource{ModuleID: m.DestinationModule, Address: m.DestinationAddress}.ID()] = true
+		hasMatch["-"+Resource{ModuleID: m.SourceModule, Address: m.SourceAddress}.ID()] = true
+	}
 
-		if planToCreateMatchCount[comparison.ToCreate.ID()] != 1 {
+	var candidates []ResourceComparison
+	for _, c := range comparisons {
+		if c.IsMatch() || hasMatch["+"+c.ToCreate.ID()] || hasMatch["-"+c.ToDelete.ID()] {
 			continue
 		}
-
-		if planToDeleteMatchCount[comparison.ToDelete.ID()] != 1 {
+		if c.Similarity() < settings.minSimilarity {
 			continue
 		}
+		candidates = append(candidates, c)
+	}
 
-		m := Move{
-			SourceModule:       comparison.ToDelete.ModuleID,
-			SourceAddress:      comparison.ToDelete.Address,
-			DestinationModule:  comparison.ToCreate.ModuleID,
-			DestinationAddress: comparison.ToCreate.Address,
-		}
-		moves = append(moves, m)
+	approximate := assign(candidates, settings.assignment)
+	for i := range approximate {
+		approximate[i].Approximate = true
 	}
 
-	// Sort the moves so that the result is deterministic.
-	sortMoves(moves)
+	return approximate
+}
 
-	return moves
+func moveFromComparison(comparison ResourceComparison) Move {
+	return Move{
+		SourceModule:       comparison.ToDelete.ModuleID,
+		SourceAddress:      comparison.ToDelete.Address,
+		DestinationModule:  comparison.ToCreate.ModuleID,
+		DestinationAddress: comparison.ToCreate.Address,
+	}
 }
 
 func sortMoves(moves []Move) {
@@ -193,3 +193,38 @@ func TestDetermineMoves(t *testing.T) {
 		})
 	}
 }
+
+func TestDetermineMovesWithReplacedResources(t *testing.T) {
+	// Two resources swap addresses, and Terraform plans to replace both.
+	// Each resource to create matches the other resource's old address.
+	resource := func(address, name string) Resource {
+		return Resource{
+			ModuleID:   "dummy_module",
+			Type:       "dummy_type",
+			Address:    address,
+			Attributes: map[string]any{"name": name},
+		}
+	}
+
+	plan := Plan{
+		ToCreate: []Resource{
+			resource("dummy_type.a", "bravo"),
+			resource("dummy_type.b", "alpha"),
+		},
+		ToDelete: []Resource{
+			resource("dummy_type.a", "alpha"),
+			resource("dummy_type.b", "bravo"),
+		},
+	}
+
+	got := DetermineMoves(CompareAll(plan, nil))
+
+	want := []Move{
+		dummyMove("dummy_type.a", "dummy_type.b"),
+		dummyMove("dummy_type.b", "dummy_type.a"),
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("got moves %v, want %v", got, want)
+	}
+}
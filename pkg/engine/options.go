@@ -0,0 +1,91 @@
+package engine
+
+type settings struct {
+	assignment    Assignment
+	minSimilarity float64
+	dependencies  Dependencies
+
+	typeEquivalences []TypeEquivalence
+
+	keyHeuristics bool
+
+	strict bool
+}
+
+// An Option configures how the engine determines moves.
+type Option func(*settings)
+
+func defaultOptions() []Option {
+	return []Option{
+		WithAssignment(AssignmentExclusive),
+	}
+}
+
+// WithAssignment sets the strategy the engine uses to pair resources when a
+// resource matches more than one other resource. If this option is not
+// provided, it defaults to AssignmentExclusive.
+func WithAssignment(a Assignment) Option {
+	return func(s *settings) {
+		s.assignment = a
+	}
+}
+
+// WithMinSimilarity allows the engine to move resources that do not match, as
+// long as their similarity is at least the given threshold. Such moves are
+// marked as approximate. A threshold of zero, the default, disables
+// approximate moves.
+//
+// Only resources without any match are considered for approximate moves.
+func WithMinSimilarity(threshold float64) Option {
+	return func(s *settings) {
+		s.minSimilarity = threshold
+	}
+}
+
+// WithDependencies provides the engine with the dependencies between
+// resources, which it uses to pair resources that match more than one other
+// resource. See Dependencies.
+func WithDependencies(deps Dependencies) Option {
+	return func(s *settings) {
+		s.dependencies = deps
+	}
+}
+
+// WithTypeEquivalences allows the engine to compare resources of different
+// types, as long as the given equivalences allow moving from one type to the
+// other. By default, the engine only compares resources of the same type. See
+// DefaultTypeEquivalences for equivalences known to work.
+func WithTypeEquivalences(eqs []TypeEquivalence) Option {
+	return func(s *settings) {
+		s.typeEquivalences = append(s.typeEquivalences, eqs...)
+	}
+}
+
+// WithKeyHeuristics allows the engine to pair instances of a resource
+// converted from count to for_each, even when they do not match, based on
+// attributes of the old instances that give away the keys of the new ones.
+// For example, `aws_subnet.this[0]` with availability_zone "eu-west-1a" can be
+// paired with `aws_subnet.this["eu-west-1a"]`. Instances that match are paired
+// as usual first. The instances paired by key do not match, so their moves are
+// marked as approximate. Disabled by default.
+func WithKeyHeuristics(enabled bool) Option {
+	return func(s *settings) {
+		s.keyHeuristics = enabled
+	}
+}
+
+// WithStrict makes the engine also compare the attributes that only the
+// resources Terraform plans to delete have. Such attributes keep the resources
+// from matching, unless a rule says otherwise. By default, the engine only
+// compares the attributes of the resources Terraform plans to create.
+func WithStrict(enabled bool) Option {
+	return func(s *settings) {
+		s.strict = enabled
+	}
+}
+
+func (s *settings) apply(opts []Option) {
+	for _, opt := range opts {
+		opt(s)
+	}
+}
@@ -16,6 +16,12 @@ type Plan struct {
 	ToCreate []Resource
 	// The resources Terraform plans to delete.
 	ToDelete []Resource
+	// The resources Terraform plans to keep, whether it updates them or not.
+	// Only their module and address are known.
+	ToKeep []Resource
+
+	// Which resources depend on which, before and after the planned changes.
+	Dependencies Dependencies
 }
 
 // SummarizeJSONPlan takes the JSON representation of a Terraform plan, as
@@ -25,12 +31,19 @@ type Plan struct {
 // The moduleID argument can be any string, but must be unique for each Plan
 // passed to the engine. Typically, it is the path to the module's directory.
 func SummarizeJSONPlan(moduleID string, jsonPlan *tfjson.Plan) (Plan, error) {
-	var planToCreate, planToDelete []Resource
+	var planToCreate, planToDelete, planToKeep []Resource
 	for _, rc := range jsonPlan.ResourceChanges {
 		isCreated := slices.Contains(rc.Change.Actions, tfjson.ActionCreate)
 		isDestroyed := slices.Contains(rc.Change.Actions, tfjson.ActionDelete)
 
 		if !isCreated && !isDestroyed {
+			if rc.Mode == tfjson.ManagedResourceMode {
+				planToKeep = append(planToKeep, Resource{
+					ModuleID: moduleID,
+					Type:     rc.Type,
+					Address:  rc.Address,
+				})
+			}
 			continue
 		}
 
@@ -40,11 +53,17 @@ func SummarizeJSONPlan(moduleID string, jsonPlan *tfjson.Plan) (Plan, error) {
 				return Plan{}, fmt.Errorf("failed to flatten attributes of %s: %w", rc.Address, err)
 			}
 
+			sensitive, err := sensitiveAttributes(rc.Change.AfterSensitive)
+			if err != nil {
+				return Plan{}, fmt.Errorf("failed to flatten sensitive attributes of %s: %w", rc.Address, err)
+			}
+
 			r := Resource{
 				ModuleID:   moduleID,
 				Type:       rc.Type,
 				Address:    rc.Address,
 				Attributes: attributes,
+				Sensitive:  sensitive,
 			}
 
 			planToCreate = append(planToCreate, r)
@@ -56,11 +75,23 @@ func SummarizeJSONPlan(moduleID string, jsonPlan *tfjson.Plan) (Plan, error) {
 				return Plan{}, fmt.Errorf("failed to flatten attributes of %s: %w", rc.Address, err)
 			}
 
+			identity, err := flatmap.Flatten(rc.Change.BeforeIdentity)
+			if err != nil {
+				return Plan{}, fmt.Errorf("failed to flatten identity of %s: %w", rc.Address, err)
+			}
+
+			sensitive, err := sensitiveAttributes(rc.Change.BeforeSensitive)
+			if err != nil {
+				return Plan{}, fmt.Errorf("failed to flatten sensitive attributes of %s: %w", rc.Address, err)
+			}
+
 			r := Resource{
 				ModuleID:   moduleID,
 				Type:       rc.Type,
 				Address:    rc.Address,
 				Attributes: attributes,
+				Identity:   identity,
+				Sensitive:  sensitive,
 			}
 
 			planToDelete = append(planToDelete, r)
@@ -68,21 +99,52 @@ func SummarizeJSONPlan(moduleID string, jsonPlan *tfjson.Plan) (Plan, error) {
 	}
 
 	return Plan{
-		ToCreate: planToCreate,
-		ToDelete: planToDelete,
+		ToCreate:     planToCreate,
+		ToDelete:     planToDelete,
+		ToKeep:       planToKeep,
+		Dependencies: dependenciesFromJSONPlan(moduleID, jsonPlan),
 	}, nil
 }
 
+// sensitiveAttributes returns the keys of the attributes marked as sensitive
+// in a change's before_sensitive or after_sensitive field. The field mirrors
+// the resource's attributes, with true wherever a value is sensitive, or is
+// false when nothing is.
+func sensitiveAttributes(marks any) ([]string, error) {
+	if _, ok := marks.(map[string]any); !ok {
+		return nil, nil
+	}
+
+	flat, err := flatmap.Flatten(marks)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for k, v := range flat {
+		if v == true {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
 // MergePlans merges the given plans into a single plan. This works because the
 // engine only cares about the resources Terraform plans to create and the
 // resources Terraform plans to delete. The module the resource is from is part
 // of the resource itself, not part of the plan.
 func MergePlans(plans []Plan) Plan {
 	var merged Plan
+	var deps []Dependencies
 	for _, p := range plans {
 		merged.ToCreate = append(merged.ToCreate, p.ToCreate...)
 		merged.ToDelete = append(merged.ToDelete, p.ToDelete...)
+		merged.ToKeep = append(merged.ToKeep, p.ToKeep...)
+		deps = append(deps, p.Dependencies)
 	}
+	merged.Dependencies = mergeDependencies(deps)
 	return merged
 }
 
@@ -93,7 +155,14 @@ func MergePlans(plans []Plan) Plan {
 // By default, the comparison checks whether the resources' attributes are
 // equal. This behavior can be tweeked by passing in engine rules that allow
 // certain differences to be ignored.
-func CompareAll(plan Plan, rules []Rule) []ResourceComparison {
+//
+// Resources of different types are only compared if an option such as
+// WithTypeEquivalences allows it. Terraform can only move resources across
+// types with moved blocks, so such resources must be in the same module.
+func CompareAll(plan Plan, rules []Rule, opts ...Option) []ResourceComparison {
+	var settings settings
+	settings.apply(append(defaultOptions(), opts...))
+
 	// First, group resources by type and the action Terraform plans to take.
 	createByType := make(map[string][]Resource)
 	deleteByType := make(map[string][]Resource)
@@ -113,10 +182,34 @@ func CompareAll(plan Plan, rules []Rule) []ResourceComparison {
 			for _, d := range deleteByType[t] {
 				if c.ID() == d.ID() {
 					// The resources are the same, so there's nothing to compare.
+					// A resource Terraform plans to replace is still compared
+					// to other resources, which is how swaps are found.
+					continue
+				}
+
+				comparison := compareResources(c, d, rules, settings.strict)
+				comparisons = append(comparisons, comparison)
+			}
+		}
+	}
+
+	// Resources of equivalent types are compared once the attributes of the
+	// resource to delete are translated to match the other type.
+	seen := make(map[[2]string]bool)
+	for _, eq := range settings.typeEquivalences {
+		pair := [2]string{eq.From, eq.To}
+		if eq.From == eq.To || seen[pair] {
+			continue
+		}
+		seen[pair] = true
+
+		for _, c := range createByType[eq.To] {
+			for _, d := range deleteByType[eq.From] {
+				if c.ModuleID != d.ModuleID {
 					continue
 				}
 
-				comparison := CompareResources(c, d, rules)
+				comparison := compareResources(c, eq.translate(d), rules, settings.strict)
 				comparisons = append(comparisons, comparison)
 			}
 		}
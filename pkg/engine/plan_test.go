@@ -1,6 +1,11 @@
 package engine
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tfjson "github.com/hashicorp/terraform-json"
+)
 
 func TestCompareAll(t *testing.T) {
 	tests := []struct {
@@ -238,3 +243,42 @@ var dummyAttributes = map[string]any{
 	"d.e.#": 2,
 	"f":     false,
 }
+
+func TestSummarizeJSONPlanSensitive(t *testing.T) {
+	jsonPlan := &tfjson.Plan{
+		ResourceChanges: []*tfjson.ResourceChange{
+			{
+				Address: "aws_db_instance.new",
+				Type:    "aws_db_instance",
+				Mode:    tfjson.ManagedResourceMode,
+				Change: &tfjson.Change{
+					Actions:        tfjson.Actions{tfjson.ActionCreate},
+					After:          map[string]any{"password": "hunter2", "tags": map[string]any{"a": "b"}},
+					AfterSensitive: map[string]any{"password": true, "tags": map[string]any{"a": true}},
+				},
+			},
+			{
+				Address: "aws_db_instance.old",
+				Type:    "aws_db_instance",
+				Mode:    tfjson.ManagedResourceMode,
+				Change: &tfjson.Change{
+					Actions:         tfjson.Actions{tfjson.ActionDelete},
+					Before:          map[string]any{"password": "hunter2"},
+					BeforeSensitive: false,
+				},
+			},
+		},
+	}
+
+	plan, err := SummarizeJSONPlan("module", jsonPlan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"password", "tags.a"}, plan.ToCreate[0].Sensitive); diff != "" {
+		t.Errorf("sensitive attributes of resource to create mismatch (-want +got):\n%s", diff)
+	}
+	if len(plan.ToDelete[0].Sensitive) != 0 {
+		t.Errorf("resource to delete has sensitive attributes %v, want none", plan.ToDelete[0].Sensitive)
+	}
+}
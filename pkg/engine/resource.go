@@ -2,7 +2,9 @@ package engine
 
 import (
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
 )
 
 // A Resource represents a Terraform resource. Whether Terraform plans
@@ -49,6 +51,15 @@ type Resource struct {
 	// A value in the flattened map is either a string, a number, a boolean, or
 	// nil. The nil value is used to represent null values in Terraform.
 	Attributes map[string]any
+
+	// The resource's identity, as reported by providers that support resource
+	// identities (Terraform v1.12+). Flattened like Attributes. Only known for
+	// resources Terraform plans to delete, and nil otherwise.
+	Identity map[string]any
+
+	// Keys of attributes Terraform marks as sensitive. A key covers the
+	// attributes nested within it, so "tags" covers "tags.Name".
+	Sensitive []string
 }
 
 // A unique ID for the resource, for use as map keys. This ID is a concatenation
@@ -58,6 +69,32 @@ func (r Resource) ID() string {
 	return fmt.Sprintf("%s:%s", r.ModuleID, r.Address)
 }
 
+// IsSensitive reports whether Terraform marks the given attribute as
+// sensitive. Sensitive values should never be shown to the user.
+func (r Resource) IsSensitive(attr string) bool {
+	for _, s := range r.Sensitive {
+		if attr == s || strings.HasPrefix(attr, s+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportID returns the ID Terraform can import an existing resource with. Most
+// providers import resources based on their "id" attribute, so that is what
+// ImportID returns. It returns an empty string if the resource has no such
+// attribute.
+//
+// Some resources are imported with a different ID, so the result is a best
+// guess. Prefer the resource's Identity when it is known.
+func (r Resource) ImportID() string {
+	id, ok := r.Attributes["id"].(string)
+	if !ok {
+		return ""
+	}
+	return id
+}
+
 // A ResourceComparison represents a pair of Terraform resources of the same
 // type: one that Terraform plans to create and another that Terraform plans to
 // delete. By comparing these resources, we can determine whether we should move
@@ -70,9 +107,12 @@ func (r Resource) ID() string {
 // An attribute is considered ignored when both resources have different values
 // for that attribute, but a rule says to ignore the difference between those
 // values.
+// An attribute is considered missing when only the resource to delete has a
+// value for that attribute. Missing attributes are only looked for in strict
+// mode.
 //
 // A ResourceComparison is considered a match when no attributes are
-// mismatching.
+// mismatching or missing.
 type ResourceComparison struct {
 	// The resource Terraform plans to create.
 	ToCreate Resource
@@ -89,17 +129,74 @@ type ResourceComparison struct {
 	// Keys of attributes that would normally be mismatching, but where the user
 	// provided a rule that says to ignore that particular difference.
 	IgnoredAttributes []string
+
+	// Keys of attributes the resource to delete has a value for, but that the
+	// resource to create does not have at all. Only set in strict mode.
+	MissingAttributes []string
 }
 
 // IsMatch returns whether the two resources are a match.
 func (rc ResourceComparison) IsMatch() bool {
-	return len(rc.MismatchingAttributes) == 0
+	return len(rc.MismatchingAttributes) == 0 && len(rc.MissingAttributes) == 0
+}
+
+// NumMismatches returns the number of attributes that keep the two resources
+// from matching: those that are mismatching and those that are missing.
+func (rc ResourceComparison) NumMismatches() int {
+	return len(rc.MismatchingAttributes) + len(rc.MissingAttributes)
+}
+
+// Weights given to each kind of attribute when measuring how similar two
+// resources are. Ignored attributes only count as partial evidence, since the
+// user chose to overlook their differences.
+const (
+	matchingWeight    = 1.0
+	ignoredWeight     = 0.5
+	mismatchingWeight = 1.0
+)
+
+// Similarity returns how similar the two resources are, as a number between 0
+// and 1. It is the weighted share of compared attributes that agree, where
+// matching attributes count fully and ignored attributes count for half.
+//
+// Two resources with no attributes to compare are considered fully similar.
+func (rc ResourceComparison) Similarity() float64 {
+	agreement := rc.agreement()
+	total := agreement + mismatchingWeight*float64(rc.NumMismatches()) + (1-ignoredWeight)*float64(len(rc.IgnoredAttributes))
+
+	if total == 0 {
+		return 1
+	}
+
+	return agreement / total
+}
+
+func (rc ResourceComparison) agreement() float64 {
+	return matchingWeight*float64(len(rc.MatchingAttributes)) + ignoredWeight*float64(len(rc.IgnoredAttributes))
+}
+
+// score rates how closely two resources resemble each other, for use when
+// choosing between several candidates. The higher the score, the better the
+// match. Unlike Similarity, the score grows with the number of attributes
+// that agree.
+func (rc ResourceComparison) score() float64 {
+	return rc.agreement() - mismatchingWeight*float64(rc.NumMismatches())
 }
 
 // CompareResources compares the attributes of two Terraform resources: one that
-// Terraform plans to create and another that Terraform plans to delete.
-func CompareResources(create, delete Resource, rules []Rule) ResourceComparison {
-	var matching, mismatching, ignored []string
+// Terraform plans to create and another that Terraform plans to delete. Only
+// the WithStrict option has an effect on the comparison.
+func CompareResources(create, delete Resource, rules []Rule, opts ...Option) ResourceComparison {
+	var settings settings
+	settings.apply(opts)
+
+	return compareResources(create, delete, rules, settings.strict)
+}
+
+func compareResources(create, delete Resource, rules []Rule, strict bool) ResourceComparison {
+	var matching, mismatching, ignored, missing []string
+
+	ignoredLists := ignoredListElements(create, delete, rules)
 
 	for key, cValue := range create.Attributes {
 		if cValue == nil {
@@ -114,19 +211,7 @@ func CompareResources(create, delete Resource, rules []Rule) ResourceComparison
 			continue
 		}
 
-		var ruleSaysToIgnore bool
-		for _, r := range rules {
-			if !r.AppliesTo(create.Type, key) {
-				continue
-			}
-
-			if r.Equates(cValue, dValue) {
-				ruleSaysToIgnore = true
-				break
-			}
-		}
-
-		if ruleSaysToIgnore {
+		if ignoredLists[key] != nil || ignoringRule(rules, create.Type, key, cValue, dValue) != nil {
 			// A rule says to ignore the difference between the two values.
 			ignored = append(ignored, key)
 			continue
@@ -136,10 +221,27 @@ func CompareResources(create, delete Resource, rules []Rule) ResourceComparison
 		mismatching = append(mismatching, key)
 	}
 
+	if strict {
+		for key, dValue := range delete.Attributes {
+			if dValue == nil || hasAttribute(create.Attributes, key) {
+				continue
+			}
+
+			if ignoredLists[key] != nil || ignoringRule(rules, create.Type, key, nil, dValue) != nil {
+				ignored = append(ignored, key)
+				continue
+			}
+
+			// The attribute was there before, and is not anymore.
+			missing = append(missing, key)
+		}
+	}
+
 	// We sort the keys so that the final diff is deterministic.
 	sort.Strings(matching)
 	sort.Strings(mismatching)
 	sort.Strings(ignored)
+	sort.Strings(missing)
 
 	return ResourceComparison{
 		ToCreate:              create,
@@ -147,5 +249,114 @@ func CompareResources(create, delete Resource, rules []Rule) ResourceComparison
 		MatchingAttributes:    matching,
 		MismatchingAttributes: mismatching,
 		IgnoredAttributes:     ignored,
+		MissingAttributes:     missing,
+	}
+}
+
+// hasAttribute reports whether the given key is set in the attributes, or
+// nested within one that is null. Terraform sets values it does not know yet
+// to null in the resources it plans to create, so their nested attributes may
+// still appear once created.
+func hasAttribute(attributes map[string]any, key string) bool {
+	if _, ok := attributes[key]; ok {
+		return true
+	}
+
+	for i := range len(key) {
+		if key[i] != '.' {
+			continue
+		}
+		if value, ok := attributes[key[:i]]; ok && value == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IgnoredBy returns the rule that makes tfautomv ignore the difference between
+// the two resources' values of the given attribute, among the given rules. It
+// returns nil if no rule does.
+func (rc ResourceComparison) IgnoredBy(attr string, rules []Rule) Rule {
+	if r := ignoredListElements(rc.ToCreate, rc.ToDelete, rules)[attr]; r != nil {
+		return r
+	}
+	return ignoringRule(rules, rc.ToCreate.Type, attr, rc.ToCreate.Attributes[attr], rc.ToDelete.Attributes[attr])
+}
+
+func ignoringRule(rules []Rule, resourceType, key string, cValue, dValue any) Rule {
+	for _, r := range rules {
+		if r.AppliesTo(resourceType, key) && r.Equates(cValue, dValue) {
+			return r
+		}
+	}
+	return nil
+}
+
+// ignoredListElements returns the keys of the attributes nested within lists
+// that a ListRule equates, along with that rule.
+func ignoredListElements(create, delete Resource, rules []Rule) map[string]Rule {
+	var listRules []ListRule
+	for _, r := range rules {
+		if lr, ok := r.(ListRule); ok {
+			listRules = append(listRules, lr)
+		}
+	}
+	if len(listRules) == 0 {
+		return nil
+	}
+
+	// Lists have a key for their length, in either resource.
+	var lists []string
+	for _, attributes := range []map[string]any{create.Attributes, delete.Attributes} {
+		for key := range attributes {
+			if list, ok := strings.CutSuffix(key, ".#"); ok {
+				lists = append(lists, list)
+			}
+		}
+	}
+
+	// Sorting puts lists before those nested within them, so that the
+	// outermost list a rule equates is the one reported.
+	sort.Strings(lists)
+	lists = slices.Compact(lists)
+
+	ignored := make(map[string]Rule)
+	for _, list := range lists {
+		for _, r := range listRules {
+			if !r.AppliesTo(create.Type, list) {
+				continue
+			}
+
+			a, b := nestedAttributes(create.Attributes, list), nestedAttributes(delete.Attributes, list)
+			if !r.EquatesLists(a, b) {
+				continue
+			}
+
+			for _, attributes := range []map[string]any{a, b} {
+				for key := range attributes {
+					if ignored[list+"."+key] == nil {
+						ignored[list+"."+key] = r
+					}
+				}
+			}
+			break
+		}
+	}
+
+	return ignored
+}
+
+// nestedAttributes returns the attributes nested within the given key, with
+// the key and the following "." removed.
+func nestedAttributes(attributes map[string]any, key string) map[string]any {
+	prefix := key + "."
+
+	nested := make(map[string]any)
+	for k, v := range attributes {
+		if rest, ok := strings.CutPrefix(k, prefix); ok {
+			nested[rest] = v
+		}
 	}
+	return nested
 }
@@ -1,6 +1,7 @@
 package engine_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/busser/tfautomv/pkg/engine"
@@ -16,10 +17,12 @@ func TestCompareResources(t *testing.T) {
 		create engine.Resource
 		delete engine.Resource
 		rules  []engine.Rule
+		strict bool
 
 		wantMatching    []string
 		wantMismatching []string
 		wantIgnored     []string
+		wantMissing     []string
 	}{
 		{
 			name: "without rules",
@@ -78,6 +81,110 @@ func TestCompareResources(t *testing.T) {
 			wantMismatching: []string{"e", "f", "h"},
 			wantIgnored:     []string{"c", "i", "j"},
 		},
+
+		{
+			name: "with list rules",
+			create: dummyResource(map[string]any{
+				"sgs.#":       2,
+				"sgs.0":       "sg-1",
+				"sgs.1":       "sg-2",
+				"ordered.#":   2,
+				"ordered.0":   "a",
+				"ordered.1":   "b",
+				"ingress.#":   2,
+				"ingress.0.a": "x",
+				"ingress.0.b": "y",
+				"ingress.1.a": "z",
+			}),
+			delete: dummyResource(map[string]any{
+				"sgs.#":       2,
+				"sgs.0":       "sg-2",
+				"sgs.1":       "sg-1",
+				"ordered.#":   2,
+				"ordered.0":   "b",
+				"ordered.1":   "a",
+				"ingress.#":   2,
+				"ingress.0.a": "z",
+				"ingress.1.a": "x",
+				"ingress.1.b": "y",
+			}),
+			rules: []engine.Rule{
+				rules.MustParse("unordered:dummy_type:sgs"),
+				rules.MustParse("unordered:dummy_type:ingress"),
+			},
+			wantMatching:    []string{"ingress.#", "ordered.#", "sgs.#"},
+			wantMismatching: []string{"ordered.0", "ordered.1"},
+			wantIgnored:     []string{"ingress.0.a", "ingress.0.b", "ingress.1.a", "sgs.0", "sgs.1"},
+		},
+		{
+			name: "with empty values",
+			create: dummyResource(map[string]any{
+				"description": "",
+				"user_data":   "",
+				"sgs.#":       0,
+				"tags.#":      0,
+				"name":        "",
+			}),
+			delete: dummyResource(map[string]any{
+				"description": nil,
+				"sgs":         nil,
+				"tags.#":      1,
+				"tags.0":      "",
+				"name":        "foo",
+			}),
+			rules: []engine.Rule{
+				rules.MustParse("empty:*:**"),
+			},
+			wantMismatching: []string{"name", "tags.#"},
+			wantIgnored:     []string{"description", "sgs.#", "user_data"},
+		},
+		{
+			name: "strict",
+			create: dummyResource(map[string]any{
+				"name":      "web",
+				"tags.Name": "web",
+				"arn":       nil,
+				"ingress":   nil,
+			}),
+			delete: dummyResource(map[string]any{
+				"name":        "web",
+				"tags.Name":   "web",
+				"tags.Owner":  "alice",
+				"tags.Team":   "",
+				"description": nil,
+				"arn":         "arn:aws:ec2:web",
+				"ingress.#":   1,
+				"ingress.0.a": "x",
+			}),
+			rules: []engine.Rule{
+				rules.MustParse("empty:dummy_type:tags.Team"),
+			},
+			strict:       true,
+			wantMatching: []string{"name", "tags.Name"},
+			wantIgnored:  []string{"tags.Team"},
+			wantMissing:  []string{"tags.Owner"},
+		},
+		{
+			name: "strict with list rules",
+			create: dummyResource(map[string]any{
+				"sgs.#":       2,
+				"sgs.0.id":    "sg-1",
+				"sgs.1.id":    "sg-2",
+				"sgs.1.extra": "x",
+			}),
+			delete: dummyResource(map[string]any{
+				"sgs.#":       2,
+				"sgs.0.id":    "sg-2",
+				"sgs.1.id":    "sg-1",
+				"sgs.0.extra": "x",
+			}),
+			rules: []engine.Rule{
+				rules.MustParse("unordered:dummy_type:sgs"),
+			},
+			strict:       true,
+			wantMatching: []string{"sgs.#"},
+			wantIgnored:  []string{"sgs.0.extra", "sgs.0.id", "sgs.1.extra", "sgs.1.id"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -88,8 +195,9 @@ func TestCompareResources(t *testing.T) {
 				MatchingAttributes:    tt.wantMatching,
 				MismatchingAttributes: tt.wantMismatching,
 				IgnoredAttributes:     tt.wantIgnored,
+				MissingAttributes:     tt.wantMissing,
 			}
-			got := engine.CompareResources(tt.create, tt.delete, tt.rules)
+			got := engine.CompareResources(tt.create, tt.delete, tt.rules, engine.WithStrict(tt.strict))
 
 			if diff := cmp.Diff(want, got); diff != "" {
 				t.Errorf("mismatch (-want +got):\n%s", diff)
@@ -99,6 +207,80 @@ func TestCompareResources(t *testing.T) {
 
 }
 
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name        string
+		matching    int
+		mismatching int
+		ignored     int
+		missing     int
+
+		want float64
+	}{
+		{
+			name: "no attributes",
+			want: 1,
+		},
+		{
+			name:     "only matching",
+			matching: 3,
+			want:     1,
+		},
+		{
+			name:        "only mismatching",
+			mismatching: 3,
+			want:        0,
+		},
+		{
+			name:        "mostly matching",
+			matching:    3,
+			mismatching: 1,
+			want:        0.75,
+		},
+		{
+			name:    "ignored count for half",
+			ignored: 2,
+			want:    0.5,
+		},
+		{
+			name:        "all kinds",
+			matching:    2,
+			mismatching: 1,
+			ignored:     2,
+			want:        0.6,
+		},
+		{
+			name:     "missing count as mismatching",
+			matching: 3,
+			missing:  1,
+			want:     0.75,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparison := engine.ResourceComparison{
+				MatchingAttributes:    attributeNames("m", tt.matching),
+				MismatchingAttributes: attributeNames("x", tt.mismatching),
+				IgnoredAttributes:     attributeNames("i", tt.ignored),
+				MissingAttributes:     attributeNames("d", tt.missing),
+			}
+
+			if got := comparison.Similarity(); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func attributeNames(prefix string, n int) []string {
+	var names []string
+	for i := range n {
+		names = append(names, fmt.Sprintf("%s%d", prefix, i))
+	}
+	return names
+}
+
 func dummyResource(attributes map[string]any) engine.Resource {
 	return engine.Resource{
 		ModuleID:   "dummy_module_id",
@@ -107,3 +289,100 @@ func dummyResource(attributes map[string]any) engine.Resource {
 		Attributes: attributes,
 	}
 }
+
+func TestImportID(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]any
+		want       string
+	}{
+		{
+			name:       "string id",
+			attributes: map[string]any{"id": "i-1234567890abcdef0"},
+			want:       "i-1234567890abcdef0",
+		},
+		{
+			name:       "no id",
+			attributes: map[string]any{"name": "foo"},
+			want:       "",
+		},
+		{
+			name:       "null id",
+			attributes: map[string]any{"id": nil},
+			want:       "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dummyResource(tt.attributes).ImportID(); got != tt.want {
+				t.Errorf("ImportID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSensitive(t *testing.T) {
+	r := engine.Resource{Sensitive: []string{"password", "tags"}}
+
+	tests := map[string]bool{
+		"password":       true,
+		"password_hash":  false,
+		"tags":           true,
+		"tags.Name":      true,
+		"tags_all.Name":  false,
+		"name":           false,
+		"settings.0.key": false,
+	}
+
+	for attr, want := range tests {
+		if got := r.IsSensitive(attr); got != want {
+			t.Errorf("IsSensitive(%q) = %v, want %v", attr, got, want)
+		}
+	}
+}
+
+func TestResourceComparisonIgnoredBy(t *testing.T) {
+	create := dummyResource(map[string]any{
+		"a": "hello",
+		"c": true,
+		"j": "some_string",
+	})
+	delete := dummyResource(map[string]any{
+		"a": "goodbye",
+		"c": false,
+		"j": "b/some_string",
+	})
+	everything := rules.MustParse("everything:dummy_type:c")
+	prefix := rules.MustParse("prefix:dummy_type:j:b/")
+	comparison := engine.CompareResources(create, delete, []engine.Rule{everything, prefix})
+
+	tests := []struct {
+		attr string
+		want engine.Rule
+	}{
+		{attr: "a", want: nil},
+		{attr: "c", want: everything},
+		{attr: "j", want: prefix},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.attr, func(t *testing.T) {
+			got := comparison.IgnoredBy(tt.attr, []engine.Rule{everything, prefix})
+			if got != tt.want {
+				t.Errorf("IgnoredBy(%q) = %v, want %v", tt.attr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResourceComparisonIgnoredByListRule(t *testing.T) {
+	create := dummyResource(map[string]any{"sgs.#": 2, "sgs.0": "sg-1", "sgs.1": "sg-2"})
+	delete := dummyResource(map[string]any{"sgs.#": 2, "sgs.0": "sg-2", "sgs.1": "sg-1"})
+	unordered := rules.MustParse("unordered:dummy_type:sgs")
+	comparison := engine.CompareResources(create, delete, []engine.Rule{unordered})
+
+	if got := comparison.IgnoredBy("sgs.0", []engine.Rule{unordered}); got != unordered {
+		t.Errorf("IgnoredBy(%q) = %v, want %v", "sgs.0", got, unordered)
+	}
+}
@@ -13,3 +13,17 @@ type Rule interface {
 	// Whether the rule equates the two values.
 	Equates(a, b interface{}) bool
 }
+
+// A ListRule is a Rule that compares lists as a whole, instead of one element
+// at a time. Once flattened, a list has a key for its length, like "attr.#",
+// and keys for its elements, like "attr.0" or "attr.0.nested". A ListRule
+// applies to a list when AppliesTo reports true for the list's own key, like
+// "attr".
+type ListRule interface {
+	Rule
+
+	// Whether the rule equates the two lists. Each list is given as the
+	// flattened attributes nested within its key, with the key and the
+	// following "." removed, like "#", "0" or "0.nested".
+	EquatesLists(a, b map[string]any) bool
+}
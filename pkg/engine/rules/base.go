@@ -3,8 +3,25 @@ package rules
 type baseRule struct {
 	resourceType string
 	attribute    string
+
+	// Compiled glob patterns. Nil when the pattern has no wildcards.
+	resourceTypeGlob *glob
+	attributeGlob    *glob
+}
+
+// newBaseRule returns a baseRule for the given resource type and attribute,
+// either of which can be a glob pattern. Patterns are compiled once, since
+// rules are matched against every attribute of every comparison.
+func newBaseRule(resourceType, attribute string) baseRule {
+	return baseRule{
+		resourceType:     resourceType,
+		attribute:        attribute,
+		resourceTypeGlob: compileGlob(resourceType),
+		attributeGlob:    compileGlob(attribute),
+	}
 }
 
 func (r baseRule) AppliesTo(resourceType, attribute string) bool {
-	return resourceType == r.resourceType && attribute == r.attribute
+	return matchGlob(r.resourceType, r.resourceTypeGlob, resourceType) &&
+		matchGlob(r.attribute, r.attributeGlob, attribute)
 }
@@ -0,0 +1,61 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/busser/tfautomv/pkg/engine"
+)
+
+type emptyRule struct {
+	baseRule
+}
+
+// Empty returns a rule that treats null values, empty strings and empty lists
+// as equal. The resource type and attribute can be glob patterns.
+func Empty(resourceType, attribute string) engine.Rule {
+	return &emptyRule{newBaseRule(resourceType, attribute)}
+}
+
+func parseEmptyRule(s string) (*emptyRule, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return nil, errors.New("syntax error")
+	}
+
+	r := emptyRule{
+		baseRule: newBaseRule(parts[0], parts[1]),
+	}
+
+	return &r, nil
+}
+
+func (r emptyRule) String() string {
+	return fmt.Sprintf("%s:%s:%s", RuleTypeEmpty, r.resourceType, r.attribute)
+}
+
+func (r *emptyRule) Equates(a, b interface{}) bool {
+	return isEmptyValue(a) && isEmptyValue(b)
+}
+
+// EquatesLists equates an empty list with another empty list, or with a list
+// that is null or missing altogether.
+func (r *emptyRule) EquatesLists(a, b map[string]any) bool {
+	return isEmptyList(a) && isEmptyList(b)
+}
+
+func isEmptyValue(v any) bool {
+	return v == nil || v == ""
+}
+
+func isEmptyList(list map[string]any) bool {
+	switch len(list) {
+	case 0:
+		return true
+	case 1:
+		return list["#"] == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,81 @@
+package rules
+
+import "testing"
+
+func TestEmptyRuleEquates(t *testing.T) {
+	tests := []struct {
+		a, b any
+		want bool
+	}{
+		{a: "", b: nil, want: true},
+		{a: nil, b: "", want: true},
+		{a: "", b: "", want: true},
+		{a: "foo", b: nil, want: false},
+		{a: "", b: "foo", want: false},
+		{a: 0, b: nil, want: false},
+		{a: false, b: nil, want: false},
+	}
+
+	rule := emptyRule{newBaseRule("my_resource", "my_attr")}
+
+	for _, tt := range tests {
+		if got := rule.Equates(tt.a, tt.b); got != tt.want {
+			t.Errorf("Equates(%#v, %#v) = %t, want %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestEmptyRuleEquatesLists(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b map[string]any
+		want bool
+	}{
+		{
+			name: "empty and missing",
+			a:    map[string]any{"#": 0},
+			b:    map[string]any{},
+			want: true,
+		},
+		{
+			name: "both empty",
+			a:    map[string]any{"#": 0},
+			b:    map[string]any{"#": 0},
+			want: true,
+		},
+		{
+			name: "empty and non-empty",
+			a:    map[string]any{"#": 0},
+			b:    map[string]any{"#": 1, "0": "sg-1"},
+			want: false,
+		},
+		{
+			name: "missing and non-empty",
+			a:    map[string]any{},
+			b:    map[string]any{"#": 1, "0": ""},
+			want: false,
+		},
+	}
+
+	rule := emptyRule{newBaseRule("my_resource", "my_attr")}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rule.EquatesLists(tt.a, tt.b); got != tt.want {
+				t.Errorf("EquatesLists() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptyRuleString(t *testing.T) {
+	for _, s := range []string{"empty:aws_instance:user_data", "empty:*:**"} {
+		if got := MustParse(s).String(); got != s {
+			t.Errorf("String() = %q, want %q", got, s)
+		}
+	}
+
+	if got := Empty("*", "**").String(); got != "empty:*:**" {
+		t.Errorf("Empty().String() = %q", got)
+	}
+}
@@ -17,10 +17,7 @@ func parseEverythingRule(s string) (*everythingRule, error) {
 	}
 
 	r := everythingRule{
-		baseRule: baseRule{
-			resourceType: parts[0],
-			attribute:    parts[1],
-		},
+		baseRule: newBaseRule(parts[0], parts[1]),
 	}
 
 	return &r, nil
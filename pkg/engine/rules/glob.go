@@ -0,0 +1,120 @@
+package rules
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Resource types and attribute keys in rules are glob patterns. In a pattern,
+// "*" matches any sequence of characters except ".", "**" matches any
+// sequence of characters, and "?" matches any single character except ".".
+// Since keys of nested attributes are joined with ".", "tags.*" matches every
+// tag, and "ingress.*.description" matches the description of every element
+// of the ingress list.
+type glob struct {
+	// The pattern split on ".", when it contains no "**". Each key segment is
+	// matched against the pattern segment in the same position.
+	segments []string
+
+	// The pattern as a regular expression, when it contains "**".
+	re *regexp.Regexp
+}
+
+// compileGlob prepares the given pattern for matching. It returns nil if the
+// pattern has no wildcards and only matches itself.
+func compileGlob(pattern string) *glob {
+	if !strings.ContainsAny(pattern, "*?") {
+		return nil
+	}
+
+	if !strings.Contains(pattern, "**") {
+		return &glob{segments: strings.Split(pattern, ".")}
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString(`[^.]*`)
+		case pattern[i] == '?':
+			b.WriteString(`[^.]`)
+		default:
+			// Wildcards are ASCII, so copying other characters one byte at a
+			// time keeps multi-byte characters intact.
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+
+	// The expression only contains quoted characters and valid wildcards, so
+	// it always compiles.
+	return &glob{re: regexp.MustCompile(b.String())}
+}
+
+// matchGlob reports whether s matches the pattern, compiled with compileGlob.
+func matchGlob(pattern string, g *glob, s string) bool {
+	switch {
+	case g == nil:
+		return s == pattern
+	case g.re != nil:
+		return g.re.MatchString(s)
+	}
+
+	for i, segment := range g.segments {
+		var part string
+		var found bool
+		part, s, found = strings.Cut(s, ".")
+		if found != (i < len(g.segments)-1) {
+			// The key has more or fewer segments than the pattern.
+			return false
+		}
+		if !matchSegment(segment, part) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchSegment reports whether s matches the pattern, where "*" matches any
+// sequence of characters and "?" any single character. Neither contains ".".
+func matchSegment(pattern, s string) bool {
+	// When a "*" is followed by a mismatch, we backtrack to just after it and
+	// let it match one more character. Backtracking to the latest "*" is
+	// enough, since it can match anything an earlier one could.
+	var p, i int
+	star, starMatch := -1, 0
+
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, starMatch = p, i
+			p++
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			if pattern[p] == '?' {
+				// Skip the whole character, which may be several bytes long.
+				_, size := utf8.DecodeRuneInString(s[i:])
+				i += size
+			} else {
+				i++
+			}
+			p++
+		case star >= 0:
+			starMatch++
+			p, i = star+1, starMatch
+		default:
+			return false
+		}
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern)
+}
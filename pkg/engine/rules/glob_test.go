@@ -0,0 +1,113 @@
+package rules
+
+import "testing"
+
+func TestBaseRuleAppliesToGlobs(t *testing.T) {
+	tests := []struct {
+		resourceType string
+		attribute    string
+
+		matches    [][2]string
+		mismatches [][2]string
+	}{
+		{
+			resourceType: "aws_*",
+			attribute:    "tags.Environment",
+			matches: [][2]string{
+				{"aws_instance", "tags.Environment"},
+				{"aws_s3_bucket", "tags.Environment"},
+			},
+			mismatches: [][2]string{
+				{"google_storage_bucket", "tags.Environment"},
+				{"aws_instance", "tags.Name"},
+			},
+		},
+		{
+			resourceType: "aws_instance",
+			attribute:    "tags.*",
+			matches: [][2]string{
+				{"aws_instance", "tags.Name"},
+				{"aws_instance", "tags."},
+			},
+			mismatches: [][2]string{
+				{"aws_instance", "tags"},
+				{"aws_instance", "tags_all.Name"},
+				{"aws_instance", "tags.kubernetes.io/role"},
+			},
+		},
+		{
+			resourceType: "aws_security_group",
+			attribute:    "ingress.*.cidr_blocks.*",
+			matches: [][2]string{
+				{"aws_security_group", "ingress.0.cidr_blocks.0"},
+				{"aws_security_group", "ingress.12.cidr_blocks.3"},
+			},
+			mismatches: [][2]string{
+				{"aws_security_group", "ingress.0.cidr_blocks"},
+				{"aws_security_group", "egress.0.cidr_blocks.0"},
+				{"aws_security_group", "ingress.0.ipv6_cidr_blocks.0"},
+			},
+		},
+		{
+			resourceType: "*",
+			attribute:    "tags.**",
+			matches: [][2]string{
+				{"aws_instance", "tags.Name"},
+				{"azurerm_resource_group", "tags.kubernetes.io/role"},
+			},
+			mismatches: [][2]string{
+				{"aws_instance", "tags"},
+			},
+		},
+		{
+			resourceType: "aws_instance",
+			attribute:    "ebs_block_device.?.volume_size",
+			matches: [][2]string{
+				{"aws_instance", "ebs_block_device.0.volume_size"},
+			},
+			mismatches: [][2]string{
+				{"aws_instance", "ebs_block_device.10.volume_size"},
+			},
+		},
+		{
+			resourceType: "aws_instance",
+			attribute:    "tags.env-*-?",
+			matches: [][2]string{
+				{"aws_instance", "tags.env-prod-é"},
+				{"aws_instance", "tags.env--1"},
+			},
+			mismatches: [][2]string{
+				{"aws_instance", "tags.env-prod-12"},
+				{"aws_instance", "tags.env-prod"},
+			},
+		},
+		{
+			// Characters with a meaning in regular expressions are literal.
+			resourceType: "aws_instance",
+			attribute:    "tags.a+b.*",
+			matches: [][2]string{
+				{"aws_instance", "tags.a+b.c"},
+			},
+			mismatches: [][2]string{
+				{"aws_instance", "tags.aab.c"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resourceType+":"+tt.attribute, func(t *testing.T) {
+			rule := newBaseRule(tt.resourceType, tt.attribute)
+
+			for _, m := range tt.matches {
+				if !rule.AppliesTo(m[0], m[1]) {
+					t.Errorf("AppliesTo(%q, %q) = false, want true", m[0], m[1])
+				}
+			}
+			for _, m := range tt.mismatches {
+				if rule.AppliesTo(m[0], m[1]) {
+					t.Errorf("AppliesTo(%q, %q) = true, want false", m[0], m[1])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// jsonOptionIAM enables normalization of AWS IAM policy documents.
+const jsonOptionIAM = "iam"
+
+type jsonRule struct {
+	baseRule
+	iam bool
+}
+
+func parseJSONRule(s string) (*jsonRule, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) < 2 {
+		return nil, errors.New("syntax error")
+	}
+
+	r := jsonRule{
+		baseRule: newBaseRule(parts[0], parts[1]),
+	}
+
+	if len(parts) == 3 {
+		if parts[2] != jsonOptionIAM {
+			return nil, fmt.Errorf("unknown option %q, only %q is supported", parts[2], jsonOptionIAM)
+		}
+		r.iam = true
+	}
+
+	return &r, nil
+}
+
+func (r jsonRule) String() string {
+	if r.iam {
+		return fmt.Sprintf("%s:%s:%s:%s", RuleTypeJSON, r.resourceType, r.attribute, jsonOptionIAM)
+	}
+	return fmt.Sprintf("%s:%s:%s", RuleTypeJSON, r.resourceType, r.attribute)
+}
+
+func (r *jsonRule) Equates(a, b interface{}) bool {
+	aStr, aOK := a.(string)
+	bStr, bOK := b.(string)
+	if !aOK || !bOK {
+		return false
+	}
+
+	var aDoc, bDoc any
+	if err := json.Unmarshal([]byte(aStr), &aDoc); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(bStr), &bDoc); err != nil {
+		return false
+	}
+
+	if r.iam {
+		aDoc = normalizeIAM(aDoc)
+		bDoc = normalizeIAM(bDoc)
+	}
+
+	return reflect.DeepEqual(aDoc, bDoc)
+}
+
+// Keys of IAM policy elements that accept either a single string or a list of
+// strings, in any order.
+var iamStringLists = map[string]bool{
+	"Action":      true,
+	"NotAction":   true,
+	"Resource":    true,
+	"NotResource": true,
+}
+
+// Keys of IAM policy elements that map to principals, each of which accepts
+// either a single string or a list of strings, in any order.
+var iamPrincipals = map[string]bool{
+	"Principal":    true,
+	"NotPrincipal": true,
+}
+
+// normalizeIAM rewrites an IAM policy document decoded from JSON so that
+// equivalent policies are deeply equal: a single statement or string becomes
+// a list of one, and lists whose order does not matter are sorted.
+func normalizeIAM(doc any) any {
+	switch v := doc.(type) {
+	case map[string]any:
+		normalized := make(map[string]any, len(v))
+		for key, value := range v {
+			switch {
+			case key == "Statement":
+				normalized[key] = sortedByJSON(asList(normalizeIAM(value)))
+			case iamStringLists[key]:
+				normalized[key] = sortedByJSON(asList(value))
+			case iamPrincipals[key]:
+				normalized[key] = normalizePrincipals(value)
+			default:
+				normalized[key] = normalizeIAM(value)
+			}
+		}
+		return normalized
+	case []any:
+		normalized := make([]any, len(v))
+		for i, value := range v {
+			normalized[i] = normalizeIAM(value)
+		}
+		return normalized
+	default:
+		return v
+	}
+}
+
+func normalizePrincipals(principals any) any {
+	m, ok := principals.(map[string]any)
+	if !ok {
+		// A principal can be "*", which is kept as is.
+		return principals
+	}
+
+	normalized := make(map[string]any, len(m))
+	for kind, values := range m {
+		normalized[kind] = sortedByJSON(asList(values))
+	}
+	return normalized
+}
+
+func asList(v any) []any {
+	if list, ok := v.([]any); ok {
+		return list
+	}
+	return []any{v}
+}
+
+// sortedByJSON returns a copy of the list sorted by the JSON encoding of its
+// elements, which orders values of any type deterministically.
+func sortedByJSON(list []any) []any {
+	type element struct {
+		value   any
+		encoded string
+	}
+
+	elements := make([]element, len(list))
+	for i, v := range list {
+		// Values decoded from JSON can always be encoded again.
+		encoded, _ := json.Marshal(v)
+		elements[i] = element{v, string(encoded)}
+	}
+
+	sort.SliceStable(elements, func(i, j int) bool {
+		return elements[i].encoded < elements[j].encoded
+	})
+
+	sorted := make([]any, len(list))
+	for i, e := range elements {
+		sorted[i] = e.value
+	}
+	return sorted
+}
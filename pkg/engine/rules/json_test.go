@@ -0,0 +1,149 @@
+package rules
+
+import "testing"
+
+func TestParseJSONRule(t *testing.T) {
+	tests := []struct {
+		s       string
+		wantIAM bool
+		wantErr bool
+	}{
+		{s: "json:aws_ecs_task_definition:container_definitions"},
+		{s: "json:aws_iam_policy:policy:iam", wantIAM: true},
+		{s: "json:aws_iam_policy:policy:yaml", wantErr: true},
+		{s: "json:aws_iam_policy", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			rule, err := Parse(tt.s)
+
+			if err != nil && !tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && tt.wantErr {
+				t.Fatalf("expected error, got none")
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if iam := rule.(*jsonRule).iam; iam != tt.wantIAM {
+				t.Errorf("iam = %t, want %t", iam, tt.wantIAM)
+			}
+			if s := rule.String(); s != tt.s {
+				t.Errorf("String() = %q, want %q", s, tt.s)
+			}
+		})
+	}
+}
+
+func TestJSONRuleEquates(t *testing.T) {
+	tests := []struct {
+		name   string
+		valueA interface{}
+		valueB interface{}
+		want   bool
+		// Whether the values are equal with IAM normalization.
+		wantIAM bool
+	}{
+		{
+			name:    "formatting",
+			valueA:  `{"name":"web","ports":[80,443]}`,
+			valueB:  "{\n  \"name\": \"web\",\n  \"ports\": [80, 443]\n}",
+			want:    true,
+			wantIAM: true,
+		},
+		{
+			name:    "key order",
+			valueA:  `{"name":"web","image":"nginx"}`,
+			valueB:  `{"image":"nginx","name":"web"}`,
+			want:    true,
+			wantIAM: true,
+		},
+		{
+			name:    "list order",
+			valueA:  `{"ports":[80,443]}`,
+			valueB:  `{"ports":[443,80]}`,
+			want:    false,
+			wantIAM: false,
+		},
+		{
+			name:    "different values",
+			valueA:  `{"name":"web"}`,
+			valueB:  `{"name":"api"}`,
+			want:    false,
+			wantIAM: false,
+		},
+		{
+			name:    "invalid JSON",
+			valueA:  `{"name":"web"}`,
+			valueB:  `{"name":"web"`,
+			want:    false,
+			wantIAM: false,
+		},
+		{
+			name:    "not strings",
+			valueA:  123,
+			valueB:  123.0,
+			want:    false,
+			wantIAM: false,
+		},
+		{
+			name:    "single action",
+			valueA:  `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`,
+			valueB:  `{"Statement":[{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["*"]}]}`,
+			want:    false,
+			wantIAM: true,
+		},
+		{
+			name:    "action order",
+			valueA:  `{"Statement":[{"Effect":"Allow","Action":["s3:GetObject","s3:PutObject"],"Resource":"*"}]}`,
+			valueB:  `{"Statement":[{"Effect":"Allow","Action":["s3:PutObject","s3:GetObject"],"Resource":"*"}]}`,
+			want:    false,
+			wantIAM: true,
+		},
+		{
+			name:    "statement order",
+			valueA:  `{"Statement":[{"Sid":"A","Effect":"Allow","Action":"s3:*"},{"Sid":"B","Effect":"Deny","Action":"ec2:*"}]}`,
+			valueB:  `{"Statement":[{"Sid":"B","Effect":"Deny","Action":"ec2:*"},{"Sid":"A","Effect":"Allow","Action":"s3:*"}]}`,
+			want:    false,
+			wantIAM: true,
+		},
+		{
+			name:    "single statement",
+			valueA:  `{"Statement":{"Effect":"Allow","Action":"s3:*"}}`,
+			valueB:  `{"Statement":[{"Effect":"Allow","Action":"s3:*"}]}`,
+			want:    false,
+			wantIAM: true,
+		},
+		{
+			name:    "single principal",
+			valueA:  `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:root"}}]}`,
+			valueB:  `{"Statement":[{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::123456789012:root"]}}]}`,
+			want:    false,
+			wantIAM: true,
+		},
+		{
+			name:    "different effect",
+			valueA:  `{"Statement":[{"Effect":"Allow","Action":"s3:*"}]}`,
+			valueB:  `{"Statement":[{"Effect":"Deny","Action":["s3:*"]}]}`,
+			want:    false,
+			wantIAM: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain := jsonRule{newBaseRule("my_resource", "my_attr"), false}
+			if got := plain.Equates(tt.valueA, tt.valueB); got != tt.want {
+				t.Errorf("Equates() = %t, want %t", got, tt.want)
+			}
+
+			iam := jsonRule{newBaseRule("my_resource", "my_attr"), true}
+			if got := iam.Equates(tt.valueA, tt.valueB); got != tt.wantIAM {
+				t.Errorf("Equates() with IAM normalization = %t, want %t", got, tt.wantIAM)
+			}
+		})
+	}
+}
@@ -12,13 +12,31 @@ import (
 type RuleType string
 
 const (
+	// RuleTypeEmpty treats null values, empty strings and empty lists as
+	// equal.
+	RuleTypeEmpty RuleType = "empty"
+
 	// RuleTypeEverything ignores all differences between two attributes'
 	// values.
 	RuleTypeEverything RuleType = "everything"
 
+	// RuleTypeJSON compares attribute values as JSON documents, ignoring
+	// differences in formatting and key order. With the "iam" option, it also
+	// ignores differences between equivalent AWS IAM policies.
+	RuleTypeJSON RuleType = "json"
+
 	// RuleTypePrefix ignores a given prefix when comparing attribute values.
 	RuleTypePrefix RuleType = "prefix"
 
+	// RuleTypeRegex replaces matches of a regular expression in attribute
+	// values before comparing them. The replacement can refer to the
+	// expression's capture groups, like $1.
+	RuleTypeRegex RuleType = "regex"
+
+	// RuleTypeUnordered ignores the order of the elements of a list, for lists
+	// that Terraform treats as sets.
+	RuleTypeUnordered RuleType = "unordered"
+
 	// RuleTypeWhitespace ignores differences in whitespace between two
 	// attributes' values. Whitespace is as defined by unicode.IsSpace.
 	RuleTypeWhitespace RuleType = "whitespace"
@@ -34,10 +52,18 @@ func Parse(s string) (engine.Rule, error) {
 	ruleType := RuleType(parts[0])
 
 	switch ruleType {
+	case RuleTypeEmpty:
+		return parseEmptyRule(parts[1])
 	case RuleTypeEverything:
 		return parseEverythingRule(parts[1])
+	case RuleTypeJSON:
+		return parseJSONRule(parts[1])
 	case RuleTypePrefix:
 		return parsePrefixRule(parts[1])
+	case RuleTypeRegex:
+		return parseRegexRule(parts[1])
+	case RuleTypeUnordered:
+		return parseUnorderedRule(parts[1])
 	case RuleTypeWhitespace:
 		return parseWhitespaceRule(parts[1])
 	default:
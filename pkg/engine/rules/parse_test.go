@@ -51,6 +51,16 @@ func TestParseRule(t *testing.T) {
 			wantErr: true,
 		},
 
+		// Glob patterns
+		{
+			s:    "everything:aws_*:tags.*",
+			want: &everythingRule{newBaseRule("aws_*", "tags.*")},
+		},
+		{
+			s:    "prefix:aws_security_group:ingress.*.description:managed-",
+			want: &prefixRule{newBaseRule("aws_security_group", "ingress.*.description"), "managed-"},
+		},
+
 		// Non-existent rule
 		{
 			s:       "doesnotexist:foo:bar",
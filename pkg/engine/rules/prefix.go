@@ -19,11 +19,8 @@ func parsePrefixRule(s string) (*prefixRule, error) {
 	}
 
 	r := prefixRule{
-		baseRule: baseRule{
-			resourceType: parts[0],
-			attribute:    parts[1],
-		},
-		prefix: parts[2],
+		baseRule: newBaseRule(parts[0], parts[1]),
+		prefix:   parts[2],
 	}
 
 	return &r, nil
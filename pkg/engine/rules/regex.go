@@ -0,0 +1,76 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+type regexRule struct {
+	baseRule
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+func parseRegexRule(s string) (*regexRule, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 {
+		return nil, errors.New("syntax error: expected regex:<resource type>:<attribute>:<pattern>:<replacement>")
+	}
+
+	// The pattern can contain colons, so it ends at the last colon that is not
+	// escaped with a backslash. Colons in the replacement must be escaped.
+	sep := lastUnescapedColon(parts[2])
+	if sep < 0 {
+		return nil, errors.New("syntax error: expected regex:<resource type>:<attribute>:<pattern>:<replacement>")
+	}
+
+	pattern, err := regexp.Compile(parts[2][:sep])
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", parts[2][:sep], err)
+	}
+
+	r := regexRule{
+		baseRule:    newBaseRule(parts[0], parts[1]),
+		pattern:     pattern,
+		replacement: strings.ReplaceAll(parts[2][sep+1:], `\:`, ":"),
+	}
+
+	return &r, nil
+}
+
+func lastUnescapedColon(s string) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == ':' && (i == 0 || s[i-1] != '\\') {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r regexRule) String() string {
+	return fmt.Sprintf("%s:%s:%s:%s:%s", RuleTypeRegex, r.resourceType, r.attribute, r.pattern, strings.ReplaceAll(r.replacement, ":", `\:`))
+}
+
+func (r *regexRule) Equates(a, b interface{}) bool {
+	aVal := reflect.ValueOf(a)
+	bVal := reflect.ValueOf(b)
+
+	if aVal.Kind() != bVal.Kind() {
+		return false
+	}
+	kind := aVal.Kind()
+
+	var aStr, bStr string
+	if kind == reflect.String {
+		aStr = aVal.String()
+		bStr = bVal.String()
+	} else {
+		aStr = fmt.Sprint(a)
+		bStr = fmt.Sprint(b)
+	}
+
+	return r.pattern.ReplaceAllString(aStr, r.replacement) == r.pattern.ReplaceAllString(bStr, r.replacement)
+}
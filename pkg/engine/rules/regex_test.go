@@ -0,0 +1,140 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRegexRule(t *testing.T) {
+	tests := []struct {
+		s           string
+		pattern     string
+		replacement string
+		wantErr     string
+	}{
+		{
+			s:           "regex:aws_iam_role:name:^(.*)-v[0-9]+$:$1",
+			pattern:     "^(.*)-v[0-9]+$",
+			replacement: "$1",
+		},
+		{
+			s:           "regex:aws_iam_role:arn:^arn:aws:iam::[0-9]{12}:(.*)$:$1",
+			pattern:     "^arn:aws:iam::[0-9]{12}:(.*)$",
+			replacement: "$1",
+		},
+		{
+			s:           `regex:aws_iam_role:arn:[0-9]{12}:ACCOUNT\:ID`,
+			pattern:     "[0-9]{12}",
+			replacement: "ACCOUNT:ID",
+		},
+		{
+			s:           "regex:aws_instance:name:-[0-9a-f]{8}$:",
+			pattern:     "-[0-9a-f]{8}$",
+			replacement: "",
+		},
+		{
+			s:       "regex:aws_instance:name",
+			wantErr: "syntax error",
+		},
+		{
+			s:       "regex:aws_instance:name:no-replacement",
+			wantErr: "syntax error",
+		},
+		{
+			s:       "regex:aws_instance:name:(unclosed:$1",
+			wantErr: `invalid pattern "(unclosed"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			rule, err := Parse(tt.s)
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error, got none")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error %q does not contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			r := rule.(*regexRule)
+			if r.pattern.String() != tt.pattern {
+				t.Errorf("pattern = %q, want %q", r.pattern, tt.pattern)
+			}
+			if r.replacement != tt.replacement {
+				t.Errorf("replacement = %q, want %q", r.replacement, tt.replacement)
+			}
+
+			if s := rule.String(); s != tt.s {
+				t.Errorf("String() = %q, want %q", s, tt.s)
+			}
+		})
+	}
+}
+
+func TestRegexRuleEquates(t *testing.T) {
+	tests := []struct {
+		rule   string
+		valueA interface{}
+		valueB interface{}
+		want   bool
+	}{
+		{
+			rule:   "regex:aws_iam_role:name:^(.*)-v[0-9]+$:$1",
+			valueA: "deployer-v2",
+			valueB: "deployer-v13",
+			want:   true,
+		},
+		{
+			rule:   "regex:aws_iam_role:name:^(.*)-v[0-9]+$:$1",
+			valueA: "deployer-v2",
+			valueB: "deployer",
+			want:   true,
+		},
+		{
+			rule:   "regex:aws_iam_role:name:^(.*)-v[0-9]+$:$1",
+			valueA: "deployer-v2",
+			valueB: "builder-v2",
+			want:   false,
+		},
+		{
+			rule:   "regex:aws_iam_role:arn:[0-9]{12}:ACCOUNT",
+			valueA: "arn:aws:iam::123456789012:role/deployer",
+			valueB: "arn:aws:iam::210987654321:role/deployer",
+			want:   true,
+		},
+		{
+			rule:   "regex:aws_iam_role:arn:[0-9]{12}:ACCOUNT",
+			valueA: "arn:aws:iam::123456789012:role/deployer",
+			valueB: "arn:aws:iam::210987654321:role/builder",
+			want:   false,
+		},
+		{
+			rule:   "regex:aws_instance:count:[0-9]:N",
+			valueA: 3,
+			valueB: 4,
+			want:   true,
+		},
+		{
+			rule:   "regex:aws_instance:count:[0-9]:N",
+			valueA: 3,
+			valueB: "4",
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		rule := MustParse(tt.rule)
+
+		actual := rule.Equates(tt.valueA, tt.valueB)
+		if actual != tt.want {
+			t.Errorf("%s: Equates(%q, %q) = %t, want %t", tt.rule, tt.valueA, tt.valueB, actual, tt.want)
+		}
+	}
+}
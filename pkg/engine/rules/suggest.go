@@ -0,0 +1,234 @@
+package rules
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/busser/tfautomv/pkg/engine"
+)
+
+// Suggest returns the narrowest rule that equates the two given values of an
+// attribute: an empty rule if both values are empty, a whitespace rule if the
+// values only differ in whitespace, a json rule if they are equivalent JSON
+// documents, a prefix rule if one value is the other with a prefix, and an
+// everything rule otherwise.
+func Suggest(resourceType, attribute string, a, b any) engine.Rule {
+	base := newBaseRule(resourceType, attribute)
+
+	if r := (&emptyRule{base}); r.Equates(a, b) {
+		return r
+	}
+
+	if r := (&whitespaceRule{base}); a != nil && b != nil && r.Equates(a, b) {
+		return r
+	}
+
+	aStr, aOK := a.(string)
+	bStr, bOK := b.(string)
+
+	if aOK && bOK && isJSONDocument(aStr) && isJSONDocument(bStr) {
+		for _, r := range []*jsonRule{{base, false}, {base, true}} {
+			if r.Equates(a, b) {
+				return r
+			}
+		}
+	}
+	if aOK && bOK && aStr != "" && bStr != "" {
+		switch {
+		case strings.HasSuffix(aStr, bStr):
+			return &prefixRule{base, strings.TrimSuffix(aStr, bStr)}
+		case strings.HasSuffix(bStr, aStr):
+			return &prefixRule{base, strings.TrimSuffix(bStr, aStr)}
+		}
+	}
+
+	return &everythingRule{base}
+}
+
+// SuggestFor returns the rules Suggest returns for each attribute the given
+// comparison's resources disagree on, including attributes missing from the
+// resource to create. Together, they would make the resources
+// match. Sensitive attributes always get an everything rule, since a prefix
+// rule would reveal part of their value.
+func SuggestFor(comparison engine.ResourceComparison) []engine.Rule {
+	var suggestions []engine.Rule
+	for _, attr := range slices.Concat(comparison.MismatchingAttributes, comparison.MissingAttributes) {
+		if comparison.ToCreate.IsSensitive(attr) || comparison.ToDelete.IsSensitive(attr) {
+			suggestions = append(suggestions, &everythingRule{newBaseRule(comparison.ToCreate.Type, attr)})
+			continue
+		}
+
+		suggestions = append(suggestions, Suggest(
+			comparison.ToCreate.Type,
+			attr,
+			comparison.ToCreate.Attributes[attr],
+			comparison.ToDelete.Attributes[attr],
+		))
+	}
+
+	sortRules(suggestions)
+
+	return suggestions
+}
+
+// isJSONDocument reports whether s looks like a JSON object or array, as
+// opposed to a string that happens to be valid JSON, like a number.
+func isJSONDocument(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")
+}
+
+func sortRules(rules []engine.Rule) {
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].String() < rules[j].String()
+	})
+}
+
+// A Suggestion is a set of rules that would let the engine find more moves.
+type Suggestion struct {
+	// The rules to add to those the user provided. There is one per attribute
+	// that kept two resources from matching.
+	Rules []engine.Rule
+
+	// Moves the engine finds with the suggested rules, but not without them.
+	Moves []engine.Move
+
+	// Moves the engine finds without the suggested rules, but not with them.
+	// This happens when the rules make a resource match several others.
+	Lost []engine.Move
+}
+
+// Suggestions looks for comparisons in the plan that are not a match, but
+// disagree on at most maxMismatches attributes. For each, it suggests the
+// rules SuggestFor returns and measures how many new moves they would unlock,
+// by running the engine again with those rules added to userRules.
+//
+// Only suggestions that unlock at least one move are returned, those that
+// unlock the most first. The given options are passed to both
+// engine.CompareAll and engine.DetermineMoves.
+func Suggestions(plan engine.Plan, userRules []engine.Rule, maxMismatches int, opts ...engine.Option) []Suggestion {
+	comparisons := engine.CompareAll(plan, userRules, opts...)
+	moves := engine.DetermineMoves(comparisons, opts...)
+
+	// Resources that were moved already need no rule.
+	moved := make(map[string]bool)
+	for _, m := range moves {
+		moved[engine.Resource{ModuleID: m.DestinationModule, Address: m.DestinationAddress}.ID()] = true
+		moved[engine.Resource{ModuleID: m.SourceModule, Address: m.SourceAddress}.ID()] = true
+	}
+
+	// Different comparisons often lead to the same rules, which we only
+	// evaluate once.
+	seen := make(map[string]bool)
+
+	var suggestions []Suggestion
+	for _, c := range comparisons {
+		if c.IsMatch() || c.NumMismatches() > maxMismatches {
+			continue
+		}
+		if moved[c.ToCreate.ID()] || moved[c.ToDelete.ID()] {
+			continue
+		}
+
+		suggested := SuggestFor(c)
+
+		key := rulesKey(suggested)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		withSuggested := append(append([]engine.Rule(nil), userRules...), suggested...)
+		newComparisons := recompare(plan, comparisons, c.ToCreate.Type, withSuggested, opts...)
+		newMoves := engine.DetermineMoves(newComparisons, opts...)
+
+		unlocked := difference(newMoves, moves)
+		if len(unlocked) == 0 {
+			continue
+		}
+
+		suggestions = append(suggestions, Suggestion{
+			Rules: suggested,
+			Moves: unlocked,
+			Lost:  difference(moves, newMoves),
+		})
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		si, sj := suggestions[i], suggestions[j]
+		if len(si.Moves)-len(si.Lost) != len(sj.Moves)-len(sj.Lost) {
+			return len(si.Moves)-len(si.Lost) > len(sj.Moves)-len(sj.Lost)
+		}
+		return rulesKey(si.Rules) < rulesKey(sj.Rules)
+	})
+
+	return suggestions
+}
+
+// recompare returns the given comparisons of the plan's resources, with those
+// of resources to create of the given type made again with the given rules.
+// Suggested rules only apply to resources of the type they name, so the other
+// comparisons are reused as they are.
+func recompare(plan engine.Plan, comparisons []engine.ResourceComparison, resourceType string, rules []engine.Rule, opts ...engine.Option) []engine.ResourceComparison {
+	subset := plan
+	subset.ToCreate = nil
+	for _, r := range plan.ToCreate {
+		if r.Type == resourceType {
+			subset.ToCreate = append(subset.ToCreate, r)
+		}
+	}
+
+	type pair struct {
+		create, delete string
+	}
+	recompared := make(map[pair]engine.ResourceComparison)
+	for _, c := range engine.CompareAll(subset, rules, opts...) {
+		recompared[pair{c.ToCreate.ID(), c.ToDelete.ID()}] = c
+	}
+
+	result := make([]engine.ResourceComparison, len(comparisons))
+	for i, c := range comparisons {
+		if r, ok := recompared[pair{c.ToCreate.ID(), c.ToDelete.ID()}]; ok {
+			c = r
+		}
+		result[i] = c
+	}
+
+	return result
+}
+
+func rulesKey(rules []engine.Rule) string {
+	var parts []string
+	for _, r := range rules {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, "\n")
+}
+
+// difference returns the moves in a that are not in b. Moves between the same
+// addresses are the same, regardless of how the engine found them.
+func difference(a, b []engine.Move) []engine.Move {
+	type endpoints struct {
+		from, to string
+	}
+	key := func(m engine.Move) endpoints {
+		return endpoints{
+			from: engine.Resource{ModuleID: m.SourceModule, Address: m.SourceAddress}.ID(),
+			to:   engine.Resource{ModuleID: m.DestinationModule, Address: m.DestinationAddress}.ID(),
+		}
+	}
+
+	inB := make(map[endpoints]bool)
+	for _, m := range b {
+		inB[key(m)] = true
+	}
+
+	var diff []engine.Move
+	for _, m := range a {
+		if !inB[key(m)] {
+			diff = append(diff, m)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,171 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/busser/tfautomv/pkg/engine"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		a, b any
+		want string
+	}{
+		{a: "foo bar", b: "foo\tbar\n", want: "whitespace:my_resource:my_attr"},
+		{a: "prod-foo", b: "foo", want: "prefix:my_resource:my_attr:prod-"},
+		{a: "foo", b: "dev:foo", want: "prefix:my_resource:my_attr:dev:"},
+		{a: `{"a": 1, "b": [2]}`, b: `{"b":[2],"a":1}`, want: "json:my_resource:my_attr"},
+		{a: `{"Statement": {"Action": "s3:GetObject"}}`, b: `{"Statement": [{"Action": ["s3:GetObject"]}]}`, want: "json:my_resource:my_attr:iam"},
+		{a: "1", b: "1.0", want: "everything:my_resource:my_attr"},
+		{a: "foo", b: "bar", want: "everything:my_resource:my_attr"},
+		{a: "foo", b: nil, want: "everything:my_resource:my_attr"},
+		{a: "", b: nil, want: "empty:my_resource:my_attr"},
+		{a: float64(1), b: float64(2), want: "everything:my_resource:my_attr"},
+		{a: "foo", b: "", want: "everything:my_resource:my_attr"},
+	}
+
+	for _, tt := range tests {
+		got := Suggest("my_resource", "my_attr", tt.a, tt.b)
+		if got.String() != tt.want {
+			t.Errorf("Suggest(%#v, %#v) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+
+		// The suggested rule must parse back to itself, and equate the values.
+		parsed, err := Parse(got.String())
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", got, err)
+			continue
+		}
+		if !parsed.Equates(tt.a, tt.b) {
+			t.Errorf("suggested rule %q does not equate %#v and %#v", got, tt.a, tt.b)
+		}
+	}
+}
+
+func TestSuggestFor(t *testing.T) {
+	comparison := engine.ResourceComparison{
+		ToCreate: engine.Resource{
+			Type:       "aws_instance",
+			Attributes: map[string]any{"name": "web", "tags.Name": "prod-web", "password": "prod-secret"},
+			Sensitive:  []string{"password"},
+		},
+		ToDelete: engine.Resource{
+			Type:       "aws_instance",
+			Attributes: map[string]any{"name": "web ", "tags.Name": "web", "password": "secret", "tags.Owner": "alice", "tags.Team": ""},
+			Sensitive:  []string{"password"},
+		},
+		MismatchingAttributes: []string{"tags.Name", "name", "password"},
+		MissingAttributes:     []string{"tags.Owner", "tags.Team"},
+	}
+
+	var got []string
+	for _, r := range SuggestFor(comparison) {
+		got = append(got, r.String())
+	}
+
+	want := []string{
+		"empty:aws_instance:tags.Team",
+		"everything:aws_instance:password",
+		"everything:aws_instance:tags.Owner",
+		"prefix:aws_instance:tags.Name:prod-",
+		"whitespace:aws_instance:name",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	resource := func(address string, attributes map[string]any) engine.Resource {
+		return engine.Resource{ModuleID: ".", Type: "aws_instance", Address: address, Attributes: attributes}
+	}
+
+	plan := engine.Plan{
+		ToCreate: []engine.Resource{
+			resource("aws_instance.a_new", map[string]any{"ami": "ami-1", "name": "a", "tags.Team": "web"}),
+			resource("aws_instance.b_new", map[string]any{"ami": "ami-2", "name": "b", "tags.Team": "web"}),
+			resource("aws_instance.c_new", map[string]any{"ami": "ami-3", "name": "c", "tags.Team": "db"}),
+			resource("aws_instance.d_new", map[string]any{"ami": "ami-4", "name": "d", "tags.Team": "db"}),
+		},
+		ToDelete: []engine.Resource{
+			resource("aws_instance.a_old", map[string]any{"ami": "ami-1", "name": "a", "tags.Team": "web"}),
+			resource("aws_instance.b_old", map[string]any{"ami": "ami-2", "name": "b", "tags.Team": "frontend"}),
+			resource("aws_instance.c_old", map[string]any{"ami": "ami-3", "name": "c", "tags.Team": "backend"}),
+			resource("aws_instance.d_old", map[string]any{"ami": "ami-x", "name": "x", "tags.Team": "other"}),
+		},
+	}
+
+	got := Suggestions(plan, nil, 1)
+
+	type summary struct {
+		Rules []string
+		Moves []string
+	}
+	var summaries []summary
+	for _, s := range got {
+		var sum summary
+		for _, r := range s.Rules {
+			sum.Rules = append(sum.Rules, r.String())
+		}
+		for _, m := range s.Moves {
+			sum.Moves = append(sum.Moves, m.SourceAddress+" -> "+m.DestinationAddress)
+		}
+		summaries = append(summaries, sum)
+	}
+
+	// The rules suggested for b and c are the same, and evaluated once. The
+	// resources d differ in too many attributes to get a suggestion.
+	want := []summary{
+		{
+			Rules: []string{"everything:aws_instance:tags.Team"},
+			Moves: []string{
+				"aws_instance.b_old -> aws_instance.b_new",
+				"aws_instance.c_old -> aws_instance.c_new",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, summaries); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRecompare(t *testing.T) {
+	resource := func(resourceType, address string, attributes map[string]any) engine.Resource {
+		return engine.Resource{ModuleID: ".", Type: resourceType, Address: address, Attributes: attributes}
+	}
+
+	plan := engine.Plan{
+		ToCreate: []engine.Resource{
+			resource("aws_instance", "aws_instance.new", map[string]any{"ami": "ami-1", "name": "new"}),
+			resource("aws_s3_bucket", "aws_s3_bucket.new", map[string]any{"bucket": "b", "name": "new"}),
+		},
+		ToDelete: []engine.Resource{
+			resource("aws_instance", "aws_instance.old", map[string]any{"ami": "ami-1", "name": "old"}),
+			resource("aws_s3_bucket", "aws_s3_bucket.old", map[string]any{"bucket": "b", "name": "old"}),
+		},
+	}
+
+	comparisons := engine.CompareAll(plan, nil)
+	rules := []engine.Rule{
+		MustParse("everything:aws_instance:name"),
+		MustParse("everything:aws_s3_bucket:name"),
+	}
+
+	got := recompare(plan, comparisons, "aws_instance", rules)
+
+	matches := make(map[string]bool)
+	for _, c := range got {
+		matches[c.ToCreate.Address] = c.IsMatch()
+	}
+
+	// The rules apply to both types, but only comparisons of the given type
+	// are made again.
+	want := map[string]bool{
+		"aws_instance.new":  true,
+		"aws_s3_bucket.new": false,
+	}
+	if diff := cmp.Diff(want, matches); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
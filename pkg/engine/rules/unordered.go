@@ -0,0 +1,95 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/busser/tfautomv/pkg/engine"
+)
+
+type unorderedRule struct {
+	baseRule
+}
+
+// Unordered returns a rule that ignores the order of the elements of a list
+// attribute, for lists that Terraform treats as sets. The resource type and
+// attribute can be glob patterns.
+func Unordered(resourceType, attribute string) engine.Rule {
+	return &unorderedRule{newBaseRule(resourceType, attribute)}
+}
+
+func parseUnorderedRule(s string) (*unorderedRule, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return nil, errors.New("syntax error")
+	}
+
+	r := unorderedRule{
+		baseRule: newBaseRule(parts[0], parts[1]),
+	}
+
+	return &r, nil
+}
+
+func (r unorderedRule) String() string {
+	return fmt.Sprintf("%s:%s:%s", RuleTypeUnordered, r.resourceType, r.attribute)
+}
+
+// Equates never equates single values. The rule only compares whole lists,
+// with EquatesLists.
+func (r *unorderedRule) Equates(a, b interface{}) bool {
+	return false
+}
+
+func (r *unorderedRule) EquatesLists(a, b map[string]any) bool {
+	aElements, ok := listElements(a)
+	if !ok {
+		return false
+	}
+	bElements, ok := listElements(b)
+	if !ok {
+		return false
+	}
+
+	return slices.Equal(aElements, bElements)
+}
+
+// listElements returns a canonical representation of each element of a
+// flattened list, sorted so that lists with the same elements in a different
+// order have the same representation. Elements can be objects, in which case
+// their own nested lists are still compared in order. Null attributes of an
+// object are left out.
+func listElements(list map[string]any) ([]string, bool) {
+	length, ok := list["#"].(int)
+	if !ok {
+		return nil, false
+	}
+
+	fields := make([][]string, length)
+	for key, value := range list {
+		if key == "#" || value == nil {
+			continue
+		}
+
+		index, field, _ := strings.Cut(key, ".")
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 || i >= length {
+			return nil, false
+		}
+
+		fields[i] = append(fields[i], fmt.Sprintf("%s=%#v", field, value))
+	}
+
+	elements := make([]string, length)
+	for i, f := range fields {
+		sort.Strings(f)
+		elements[i] = strings.Join(f, "\n")
+	}
+	sort.Strings(elements)
+
+	return elements, true
+}
@@ -0,0 +1,108 @@
+package rules
+
+import "testing"
+
+func TestUnorderedRuleEquatesLists(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b map[string]any
+		want bool
+	}{
+		{
+			name: "same order",
+			a:    map[string]any{"#": 2, "0": "sg-1", "1": "sg-2"},
+			b:    map[string]any{"#": 2, "0": "sg-1", "1": "sg-2"},
+			want: true,
+		},
+		{
+			name: "different order",
+			a:    map[string]any{"#": 2, "0": "sg-1", "1": "sg-2"},
+			b:    map[string]any{"#": 2, "0": "sg-2", "1": "sg-1"},
+			want: true,
+		},
+		{
+			name: "different elements",
+			a:    map[string]any{"#": 2, "0": "sg-1", "1": "sg-2"},
+			b:    map[string]any{"#": 2, "0": "sg-1", "1": "sg-3"},
+			want: false,
+		},
+		{
+			name: "duplicate elements",
+			a:    map[string]any{"#": 2, "0": "sg-1", "1": "sg-1"},
+			b:    map[string]any{"#": 2, "0": "sg-1", "1": "sg-2"},
+			want: false,
+		},
+		{
+			name: "different lengths",
+			a:    map[string]any{"#": 1, "0": "sg-1"},
+			b:    map[string]any{"#": 2, "0": "sg-1", "1": "sg-1"},
+			want: false,
+		},
+		{
+			name: "objects in different order",
+			a: map[string]any{
+				"#":               2,
+				"0.from_port":     80,
+				"0.cidr_blocks.#": 1,
+				"0.cidr_blocks.0": "10.0.0.0/8",
+				"1.from_port":     443,
+				"1.cidr_blocks.#": 0,
+			},
+			b: map[string]any{
+				"#":               2,
+				"0.from_port":     443,
+				"0.cidr_blocks.#": 0,
+				"1.from_port":     80,
+				"1.cidr_blocks.#": 1,
+				"1.cidr_blocks.0": "10.0.0.0/8",
+			},
+			want: true,
+		},
+		{
+			name: "objects with different fields",
+			a:    map[string]any{"#": 2, "0.a": 1, "0.b": 2, "1.a": 3},
+			b:    map[string]any{"#": 2, "0.a": 3, "1.a": 1, "1.b": 3},
+			want: false,
+		},
+		{
+			name: "null fields",
+			a:    map[string]any{"#": 1, "0.a": 1, "0.b": nil},
+			b:    map[string]any{"#": 1, "0.a": 1},
+			want: true,
+		},
+		{
+			name: "nested lists in different order",
+			a:    map[string]any{"#": 1, "0.l.#": 2, "0.l.0": "x", "0.l.1": "y"},
+			b:    map[string]any{"#": 1, "0.l.#": 2, "0.l.0": "y", "0.l.1": "x"},
+			want: false,
+		},
+		{
+			name: "missing list",
+			a:    map[string]any{"#": 1, "0": "sg-1"},
+			b:    map[string]any{},
+			want: false,
+		},
+	}
+
+	rule := unorderedRule{newBaseRule("my_resource", "my_attr")}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rule.EquatesLists(tt.a, tt.b); got != tt.want {
+				t.Errorf("EquatesLists() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnorderedRuleString(t *testing.T) {
+	for _, s := range []string{"unordered:aws_instance:vpc_security_group_ids", "unordered:aws_*:ingress.*.cidr_blocks"} {
+		if got := MustParse(s).String(); got != s {
+			t.Errorf("String() = %q, want %q", got, s)
+		}
+	}
+
+	if got := Unordered("aws_instance", "vpc_security_group_ids").String(); got != "unordered:aws_instance:vpc_security_group_ids" {
+		t.Errorf("Unordered().String() = %q", got)
+	}
+}
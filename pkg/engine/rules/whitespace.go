@@ -19,10 +19,7 @@ func parseWhitespaceRule(s string) (*whitespaceRule, error) {
 	}
 
 	r := whitespaceRule{
-		baseRule: baseRule{
-			resourceType: parts[0],
-			attribute:    parts[1],
-		},
+		baseRule: newBaseRule(parts[0], parts[1]),
 	}
 
 	return &r, nil
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A TypeEquivalence declares that resources of one type can be moved to
+// another type. Terraform supports such moves with moved blocks, starting with
+// v1.8, when the provider of the new type knows how to convert the state.
+//
+// Both types may name some attributes differently. Attributes translates the
+// names used by the old type to those used by the new type, so that resources
+// of both types can be compared.
+type TypeEquivalence struct {
+	// The type of the resource Terraform plans to delete.
+	From string
+	// The type of the resource Terraform plans to create.
+	To string
+
+	// Maps attribute names of the From type to attribute names of the To type.
+	// Nested attributes are translated along with their parent.
+	Attributes map[string]string
+}
+
+// DefaultTypeEquivalences lists type equivalences known to be supported by
+// Terraform and the providers involved.
+var DefaultTypeEquivalences = []TypeEquivalence{
+	{From: "aws_alb", To: "aws_lb"},
+	{From: "aws_alb_listener", To: "aws_lb_listener"},
+	{From: "aws_alb_listener_certificate", To: "aws_lb_listener_certificate"},
+	{From: "aws_alb_listener_rule", To: "aws_lb_listener_rule"},
+	{From: "aws_alb_target_group", To: "aws_lb_target_group"},
+	{From: "aws_alb_target_group_attachment", To: "aws_lb_target_group_attachment"},
+	{
+		From: "null_resource",
+		To:   "terraform_data",
+		Attributes: map[string]string{
+			"triggers": "triggers_replace",
+		},
+	},
+}
+
+// ParseTypeEquivalence converts a string into a TypeEquivalence. The string
+// has the following syntax:
+//
+//	<FROM TYPE>:<TO TYPE>[:<FROM ATTRIBUTE>=<TO ATTRIBUTE>,...]
+//
+// For example: "null_resource:terraform_data:triggers=triggers_replace".
+func ParseTypeEquivalence(s string) (TypeEquivalence, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return TypeEquivalence{}, errors.New("invalid syntax")
+	}
+
+	eq := TypeEquivalence{
+		From: parts[0],
+		To:   parts[1],
+	}
+
+	if eq.From == "" || eq.To == "" {
+		return TypeEquivalence{}, errors.New("resource types cannot be empty")
+	}
+
+	if len(parts) == 3 {
+		eq.Attributes = make(map[string]string)
+		for _, translation := range strings.Split(parts[2], ",") {
+			from, to, ok := strings.Cut(translation, "=")
+			if !ok || from == "" || to == "" {
+				return TypeEquivalence{}, fmt.Errorf("invalid attribute translation %q", translation)
+			}
+			eq.Attributes[from] = to
+		}
+	}
+
+	return eq, nil
+}
+
+// String returns the equivalence in the syntax ParseTypeEquivalence accepts.
+func (eq TypeEquivalence) String() string {
+	s := eq.From + ":" + eq.To
+
+	var translations []string
+	for from, to := range eq.Attributes {
+		translations = append(translations, from+"="+to)
+	}
+	if len(translations) > 0 {
+		slices.Sort(translations)
+		s += ":" + strings.Join(translations, ",")
+	}
+
+	return s
+}
+
+// translate returns a copy of the given resource, with its attributes renamed
+// to match the equivalence's To type. The resource keeps its original type and
+// address.
+func (eq TypeEquivalence) translate(r Resource) Resource {
+	if len(eq.Attributes) == 0 {
+		return r
+	}
+
+	translated := r
+	translated.Attributes = make(map[string]any, len(r.Attributes))
+
+	for key, value := range r.Attributes {
+		translated.Attributes[eq.translateKey(key)] = value
+	}
+
+	return translated
+}
+
+func (eq TypeEquivalence) translateKey(key string) string {
+	for from, to := range eq.Attributes {
+		if key == from {
+			return to
+		}
+		if rest, ok := strings.CutPrefix(key, from+"."); ok {
+			return to + "." + rest
+		}
+	}
+	return key
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTypeEquivalence(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    TypeEquivalence
+		wantErr bool
+	}{
+		{
+			input: "aws_alb:aws_lb",
+			want:  TypeEquivalence{From: "aws_alb", To: "aws_lb"},
+		},
+		{
+			input: "null_resource:terraform_data:triggers=triggers_replace",
+			want: TypeEquivalence{
+				From:       "null_resource",
+				To:         "terraform_data",
+				Attributes: map[string]string{"triggers": "triggers_replace"},
+			},
+		},
+		{
+			input: "a:b:c=d,e=f",
+			want: TypeEquivalence{
+				From:       "a",
+				To:         "b",
+				Attributes: map[string]string{"c": "d", "e": "f"},
+			},
+		},
+		{input: "aws_alb", wantErr: true},
+		{input: ":aws_lb", wantErr: true},
+		{input: "aws_alb:", wantErr: true},
+		{input: "a:b:c", wantErr: true},
+		{input: "a:b:c=", wantErr: true},
+		{input: "a:b:c=d:e", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTypeEquivalence(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+			if got.String() != tt.input {
+				t.Errorf("String() = %q, want %q", got.String(), tt.input)
+			}
+		})
+	}
+}
+
+func TestCompareAllWithTypeEquivalences(t *testing.T) {
+	nullResource := Resource{
+		ModuleID: "same_module",
+		Type:     "null_resource",
+		Address:  "null_resource.this",
+		Attributes: map[string]any{
+			"id":         "1234",
+			"triggers.%": 1,
+			"triggers.a": "foo",
+		},
+	}
+	terraformData := Resource{
+		ModuleID: "same_module",
+		Type:     "terraform_data",
+		Address:  "terraform_data.this",
+		Attributes: map[string]any{
+			"triggers_replace.%": 1,
+			"triggers_replace.a": "foo",
+		},
+	}
+
+	plan := Plan{
+		ToCreate: []Resource{
+			terraformData,
+			dummyResource("same_module", "aws_lb", "aws_lb.this"),
+			dummyResource("that_module", "aws_lb", "aws_lb.that"),
+		},
+		ToDelete: []Resource{
+			nullResource,
+			dummyResource("same_module", "aws_alb", "aws_alb.this"),
+		},
+	}
+
+	if got := len(CompareAll(plan, nil)); got != 0 {
+		t.Errorf("got %d comparisons without type equivalences, want 0", got)
+	}
+
+	comparisons := CompareAll(plan, nil, WithTypeEquivalences(DefaultTypeEquivalences))
+
+	// Resources in different modules cannot be moved across types.
+	if got, want := len(comparisons), 2; got != want {
+		t.Fatalf("got %d comparisons, want %d", got, want)
+	}
+
+	for _, c := range comparisons {
+		if !c.IsMatch() {
+			t.Errorf("%s and %s should match, mismatching attributes: %v", c.ToCreate.Address, c.ToDelete.Address, c.MismatchingAttributes)
+		}
+		if c.ToDelete.Type == c.ToCreate.Type {
+			t.Errorf("comparison of %s and %s should be across types", c.ToCreate.Address, c.ToDelete.Address)
+		}
+	}
+
+	// The same equivalence passed twice does not duplicate comparisons.
+	twice := slices.Concat(DefaultTypeEquivalences, DefaultTypeEquivalences)
+	if got := len(CompareAll(plan, nil, WithTypeEquivalences(twice))); got != 2 {
+		t.Errorf("got %d comparisons with duplicate equivalences, want 2", got)
+	}
+}
@@ -0,0 +1,180 @@
+package pretty
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/busser/tfautomv/pkg/engine"
+	"github.com/busser/tfautomv/pkg/engine/rules"
+)
+
+// ExplainResourceToCreate presents every resource tfautomv compared the given
+// resource to create against, from most to least similar, along with what
+// kept each of them from matching. The given rules are those the comparisons
+// were made with.
+func (s *Summarizer) ExplainResourceToCreate(r engine.Resource, rules []engine.Rule) string {
+	var comparisons []engine.ResourceComparison
+	for _, c := range s.comparisons {
+		if c.ToCreate.ID() == r.ID() {
+			comparisons = append(comparisons, c)
+		}
+	}
+
+	var moved []string
+	for _, m := range s.moves {
+		if m.DestinationModule == r.ModuleID && m.DestinationAddress == r.Address {
+			from := engine.Resource{ModuleID: m.SourceModule, Address: m.SourceAddress}
+			moved = append(moved, Colorf("moved from %s", s.annotatedResource(from, s.annotationDelete())))
+		}
+	}
+
+	candidate := func(c engine.ResourceComparison) engine.Resource { return c.ToDelete }
+
+	return s.explanation(s.annotatedResource(r, s.annotationCreate()), moved, comparisons, candidate, s.annotationDelete(), rules)
+}
+
+// ExplainResourceToDelete is like ExplainResourceToCreate, for a resource
+// Terraform plans to delete.
+func (s *Summarizer) ExplainResourceToDelete(r engine.Resource, rules []engine.Rule) string {
+	var comparisons []engine.ResourceComparison
+	for _, c := range s.comparisons {
+		if c.ToDelete.ID() == r.ID() {
+			comparisons = append(comparisons, c)
+		}
+	}
+
+	var moved []string
+	for _, m := range s.moves {
+		if m.SourceModule == r.ModuleID && m.SourceAddress == r.Address {
+			to := engine.Resource{ModuleID: m.DestinationModule, Address: m.DestinationAddress}
+			moved = append(moved, Colorf("moved to %s", s.annotatedResource(to, s.annotationCreate())))
+		}
+	}
+
+	candidate := func(c engine.ResourceComparison) engine.Resource { return c.ToCreate }
+
+	return s.explanation(s.annotatedResource(r, s.annotationDelete()), moved, comparisons, candidate, s.annotationCreate(), rules)
+}
+
+func (s *Summarizer) explanation(resource string, moved []string, comparisons []engine.ResourceComparison, candidate func(engine.ResourceComparison) engine.Resource, annotation string, userRules []engine.Rule) string {
+	// Best candidates first. Ties are broken by address, so that the
+	// explanation is deterministic.
+	comparisons = append([]engine.ResourceComparison(nil), comparisons...)
+	sort.SliceStable(comparisons, func(i, j int) bool {
+		si, sj := comparisons[i].Similarity(), comparisons[j].Similarity()
+		if si != sj {
+			return si > sj
+		}
+		return candidate(comparisons[i]).ID() < candidate(comparisons[j]).ID()
+	})
+
+	matches := 0
+	for _, c := range comparisons {
+		if c.IsMatch() {
+			matches++
+		}
+	}
+
+	headline := Colorf("%s was compared against %s", resource, styledNumCandidates(len(comparisons)))
+
+	var status string
+	switch {
+	case len(moved) > 0:
+		status = strings.Join(moved, "\n")
+	case matches > 1:
+		status = Colorf("%s, so tfautomv cannot tell which one to move", StyledNumMatches(matches))
+	case matches == 1:
+		status = Colorf("%s, but it was not moved", StyledNumMatches(matches))
+	default:
+		status = Colorf("%s, so nothing was moved", StyledNumMatches(0))
+	}
+
+	parts := []string{headline, status}
+
+	if len(comparisons) > 0 {
+		var candidates []string
+		for _, c := range comparisons {
+			candidates = append(candidates, s.styledCandidate(c, candidate(c), annotation, userRules))
+		}
+		parts = append(parts, BoxItems(candidates, "magenta"))
+	}
+
+	if len(moved) == 0 && matches == 0 && len(comparisons) > 0 {
+		parts = append(parts, styledSuggestions(rules.SuggestFor(comparisons[0])))
+	}
+
+	if legend := s.legend(); legend != "" {
+		parts = append([]string{parts[0], legend}, parts[1:]...)
+	}
+
+	return BoxSection("Explanation", strings.Join(parts, "\n\n"), "cyan")
+}
+
+// styledCandidate presents one of the resources compared against the resource
+// being explained, with every attribute that differs between the two.
+func (s *Summarizer) styledCandidate(c engine.ResourceComparison, r engine.Resource, annotation string, userRules []engine.Rule) string {
+	verdict := Color("[green][bold]match")
+	if !c.IsMatch() {
+		verdict = Color("[red][bold]no match")
+	}
+
+	lines := []string{
+		Colorf("%s, %s similar, %s", s.annotatedResource(r, annotation), StyledSimilarity(c.Similarity()), verdict),
+	}
+
+	if len(c.IgnoredAttributes) > 0 {
+		lines = append(lines, "")
+		for _, attr := range c.IgnoredAttributes {
+			line := Colorf("%s %s", s.symbolIgnored(), attr)
+			if rule := c.IgnoredBy(attr, userRules); rule != nil {
+				line += Colorf(" (ignored by [bold]%s[reset])", rule.String())
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	if len(c.MismatchingAttributes) > 0 {
+		lines = append(lines, "")
+		for _, attr := range c.MismatchingAttributes {
+			create, remove := "(sensitive)", "(sensitive)"
+			if !c.ToCreate.IsSensitive(attr) && !c.ToDelete.IsSensitive(attr) {
+				create = fmt.Sprintf("%#v", c.ToCreate.Attributes[attr])
+				remove = fmt.Sprintf("%#v", c.ToDelete.Attributes[attr])
+			}
+			lines = append(lines, Colorf("%s %s = %s", s.symbolCreate(), attr, create))
+			lines = append(lines, Colorf("%s %s = %s", s.symbolDelete(), attr, remove))
+		}
+	}
+
+	if len(c.MissingAttributes) > 0 {
+		lines = append(lines, "")
+		for _, attr := range c.MissingAttributes {
+			remove := "(sensitive)"
+			if !c.ToDelete.IsSensitive(attr) {
+				remove = fmt.Sprintf("%#v", c.ToDelete.Attributes[attr])
+			}
+			lines = append(lines, Colorf("%s %s = %s (present before, absent after)", s.symbolDelete(), attr, remove))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// styledSuggestions presents rules that would make the most similar candidate
+// match, as flags the user can pass to tfautomv.
+func styledSuggestions(suggestions []engine.Rule) string {
+	lines := []string{"these rules would make the most similar candidate match:"}
+	for _, r := range suggestions {
+		lines = append(lines, Colorf("  [bold]--ignore=%q", r.String()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func styledNumCandidates(n int) string {
+	if n == 1 {
+		return Color("[bold][magenta]1 candidate")
+	}
+
+	return Colorf("[bold][magenta]%d candidates", n)
+}
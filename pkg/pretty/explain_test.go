@@ -0,0 +1,65 @@
+package pretty
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/busser/tfautomv/pkg/engine"
+	"github.com/busser/tfautomv/pkg/engine/rules"
+	"github.com/busser/tfautomv/pkg/golden"
+)
+
+func TestExplanation(t *testing.T) {
+	resource := func(address string, attributes map[string]any) engine.Resource {
+		return engine.Resource{
+			ModuleID:   ".",
+			Type:       "aws_instance",
+			Address:    address,
+			Attributes: attributes,
+			Sensitive:  []string{"user_data"},
+		}
+	}
+
+	var (
+		web    = resource("aws_instance.web", map[string]any{"ami": "ami-123", "name": "web", "tags.Env": "prod", "user_data": "new"})
+		old    = resource("aws_instance.old", map[string]any{"ami": "ami-123", "name": "web", "tags.Env": "production", "user_data": "new"})
+		db     = resource("aws_instance.db", map[string]any{"ami": "ami-456", "name": "db", "tags.Env": "prod", "user_data": "new"})
+		legacy = resource("aws_instance.legacy", map[string]any{"ami": "ami-123", "name": "team/web", "tags.Env": "prod", "user_data": "old"})
+	)
+
+	userRules := []engine.Rule{rules.MustParse("everything:aws_instance:tags.Env")}
+
+	plan := engine.Plan{
+		ToCreate: []engine.Resource{web, db},
+		ToDelete: []engine.Resource{old, legacy},
+	}
+	comparisons := engine.CompareAll(plan, userRules)
+	moves := engine.DetermineMoves(comparisons)
+
+	tests := []struct {
+		name    string
+		explain func(s *Summarizer) string
+	}{
+		{
+			name:    "moved",
+			explain: func(s *Summarizer) string { return s.ExplainResourceToCreate(web, userRules) },
+		},
+		{
+			name:    "without match",
+			explain: func(s *Summarizer) string { return s.ExplainResourceToDelete(legacy, userRules) },
+		},
+	}
+
+	for _, colorsEnabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("colors %v", colorsEnabled), func(t *testing.T) {
+			setupColors(t, colorsEnabled)
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					summarizer := NewSummarizer(moves, comparisons, 0)
+					golden.Equal(t, tt.explain(&summarizer))
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package pretty
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/busser/tfautomv/pkg/engine"
+)
+
+// maxMarkdownDifferences is how many differences the Markdown report lists for
+// each resource without a match.
+const maxMarkdownDifferences = 5
+
+// Markdown presents the same findings as Summary, as a Markdown report suited
+// for pull request comments. Details are folded into collapsible sections. The
+// given rules are listed as tfautomv used them, and the given moved blocks are
+// included for readers to copy. Sensitive values are redacted.
+func (s *Summarizer) Markdown(rules []string, movedBlocks string) string {
+	var approximate int
+	for _, m := range s.moves {
+		if m.Approximate {
+			approximate++
+		}
+	}
+
+	headline := fmt.Sprintf("tfautomv made **%s** and found **%s**", plural(len(s.comparisons), "comparison"), plural(len(s.moves), "move"))
+	if approximate > 0 {
+		headline += fmt.Sprintf(", **%d approximate**", approximate)
+	}
+
+	parts := []string{"### tfautomv", headline + "."}
+
+	for _, module := range s.modulesWithMoves {
+		if section := s.markdownMovesWithinModule(module); section != "" {
+			parts = append(parts, section)
+		}
+	}
+	for _, fromModule := range s.modulesWithMoves {
+		for _, toModule := range s.modulesWithMoves {
+			if fromModule == toModule {
+				continue
+			}
+			if section := s.markdownMovesBetweenModules(fromModule, toModule); section != "" {
+				parts = append(parts, section)
+			}
+		}
+	}
+
+	if section := s.markdownAmbiguous(); section != "" {
+		parts = append(parts, section)
+	}
+	if section := s.markdownUnmatched(); section != "" {
+		parts = append(parts, section)
+	}
+
+	if len(rules) > 0 {
+		var lines []string
+		for _, r := range rules {
+			lines = append(lines, "- "+markdownCode(r))
+		}
+		parts = append(parts, markdownDetails("Rules used", strings.Join(lines, "\n")))
+	}
+
+	if movedBlocks != "" {
+		parts = append(parts, markdownDetails("Moved blocks", "```hcl\n"+strings.TrimRight(movedBlocks, "\n")+"\n```"))
+	}
+
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+func (s *Summarizer) markdownMovesWithinModule(module string) string {
+	var movesWithin []engine.Move
+	for _, m := range s.moves {
+		if m.SourceModule == module && m.DestinationModule == module {
+			movesWithin = append(movesWithin, m)
+		}
+	}
+
+	if len(movesWithin) == 0 {
+		return ""
+	}
+
+	// As in the summary, moves that are part of a count to for_each
+	// conversion are presented together, after the other moves.
+	conversions := engine.Conversions(movesWithin)
+	converted := make(map[engine.Move]bool)
+	for _, c := range conversions {
+		for _, m := range c.Moves {
+			converted[m] = true
+		}
+	}
+
+	var others []engine.Move
+	for _, m := range movesWithin {
+		if !converted[m] {
+			others = append(others, m)
+		}
+	}
+
+	var content []string
+	if len(others) > 0 {
+		content = append(content, s.markdownMovesTable(others))
+	}
+	for _, c := range conversions {
+		content = append(content, s.markdownConversion(c))
+	}
+
+	title := fmt.Sprintf("%s within %s", plural(len(movesWithin), "move"), htmlModule(module))
+
+	return markdownDetails(title, strings.Join(content, "\n\n"))
+}
+
+func (s *Summarizer) markdownMovesBetweenModules(fromModule, toModule string) string {
+	var moves []engine.Move
+	for _, m := range s.moves {
+		if m.SourceModule == fromModule && m.DestinationModule == toModule {
+			moves = append(moves, m)
+		}
+	}
+
+	if len(moves) == 0 {
+		return ""
+	}
+
+	title := fmt.Sprintf("%s from %s to %s", plural(len(moves), "move"), htmlModule(fromModule), htmlModule(toModule))
+
+	return markdownDetails(title, s.markdownMovesTable(moves))
+}
+
+func (s *Summarizer) markdownMovesTable(moves []engine.Move) string {
+	rows := []string{
+		"| From | To | Notes |",
+		"| --- | --- | --- |",
+	}
+
+	for _, m := range moves {
+		rows = append(rows, fmt.Sprintf("| %s | %s | %s |", markdownCode(m.SourceAddress), markdownCode(m.DestinationAddress), s.markdownMoveNotes(m)))
+	}
+
+	return strings.Join(rows, "\n")
+}
+
+func (s *Summarizer) markdownConversion(c engine.Conversion) string {
+	rows := []string{
+		fmt.Sprintf("Convert %s from `count` to `for_each`:", markdownCode(c.Address)),
+		"",
+		"| Old key | New key | Notes |",
+		"| --- | --- | --- |",
+	}
+
+	for _, m := range c.Moves {
+		fromKey := strings.TrimPrefix(m.SourceAddress, c.Address)
+		toKey := strings.TrimPrefix(m.DestinationAddress, c.Address)
+		rows = append(rows, fmt.Sprintf("| %s | %s | %s |", markdownCode(fromKey), markdownCode(toKey), s.markdownMoveNotes(m)))
+	}
+
+	return strings.Join(rows, "\n")
+}
+
+func (s *Summarizer) markdownMoveNotes(m engine.Move) string {
+	comp := s.findComparison(m)
+
+	var notes []string
+	if m.BestEffort {
+		notes = append(notes, "best effort")
+	}
+	if m.Approximate {
+		notes = append(notes, fmt.Sprintf("approximate, %.0f%% similar", comp.Similarity()*100))
+	}
+	if m.KeyAttribute != "" {
+		notes = append(notes, "paired by "+markdownCode(m.KeyAttribute))
+	}
+	switch n := comp.NumMismatches(); n {
+	case 0:
+	case 1:
+		notes = append(notes, "1 attribute differs")
+	default:
+		notes = append(notes, fmt.Sprintf("%d attributes differ", n))
+	}
+	if n := len(comp.IgnoredAttributes); n > 0 {
+		notes = append(notes, plural(n, "attribute")+" ignored")
+	}
+
+	return strings.Join(notes, ", ")
+}
+
+func (s *Summarizer) markdownAmbiguous() string {
+	type row struct {
+		id, line string
+	}
+	var rows []row
+
+	for id, r := range s.resourcesToCreateByID {
+		if s.matchCountToCreateByID[id] > 1 && !s.movedToCreateByID[id] {
+			var matches []string
+			for _, c := range s.comparisons {
+				if c.ToCreate.ID() == id && c.IsMatch() {
+					matches = append(matches, markdownResource(c.ToDelete))
+				}
+			}
+			sort.Strings(matches)
+			rows = append(rows, row{"+" + id, fmt.Sprintf("| %s | create | %s |", markdownResource(r), strings.Join(matches, "<br>"))})
+		}
+	}
+
+	for id, r := range s.resourcesToDeleteByID {
+		if s.matchCountToDeleteByID[id] > 1 && !s.movedToDeleteByID[id] {
+			var matches []string
+			for _, c := range s.comparisons {
+				if c.ToDelete.ID() == id && c.IsMatch() {
+					matches = append(matches, markdownResource(c.ToCreate))
+				}
+			}
+			sort.Strings(matches)
+			rows = append(rows, row{"-" + id, fmt.Sprintf("| %s | delete | %s |", markdownResource(r), strings.Join(matches, "<br>"))})
+		}
+	}
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].id < rows[j].id })
+
+	lines := []string{
+		"| Resource | Action | Matches |",
+		"| --- | --- | --- |",
+	}
+	for _, r := range rows {
+		lines = append(lines, r.line)
+	}
+
+	title := fmt.Sprintf("%s with several matches", plural(len(rows), "resource"))
+
+	return markdownDetails(title, strings.Join(lines, "\n"))
+}
+
+func (s *Summarizer) markdownUnmatched() string {
+	type row struct {
+		id, line string
+	}
+	var rows []row
+
+	// closest returns the comparison most similar to the resource, among
+	// those with resources that have no match either.
+	closest := func(match func(c engine.ResourceComparison) bool) (engine.ResourceComparison, bool) {
+		var best engine.ResourceComparison
+		found := false
+		for _, c := range s.comparisons {
+			if !match(c) || c.IsMatch() {
+				continue
+			}
+			if !found || c.Similarity() > best.Similarity() {
+				best, found = c, true
+			}
+		}
+		return best, found
+	}
+
+	for id, r := range s.resourcesToCreateByID {
+		if s.matchCountToCreateByID[id] == 0 && !s.movedToCreateByID[id] {
+			c, ok := closest(func(c engine.ResourceComparison) bool {
+				return c.ToCreate.ID() == id && s.matchCountToDeleteByID[c.ToDelete.ID()] == 0
+			})
+			candidate, differences := "", ""
+			if ok {
+				candidate = fmt.Sprintf("%s (%.0f%%)", markdownResource(c.ToDelete), c.Similarity()*100)
+				differences = markdownDifferences(c)
+			}
+			rows = append(rows, row{"+" + id, fmt.Sprintf("| %s | create | %s | %s |", markdownResource(r), candidate, differences)})
+		}
+	}
+
+	for id, r := range s.resourcesToDeleteByID {
+		if s.matchCountToDeleteByID[id] == 0 && !s.movedToDeleteByID[id] {
+			c, ok := closest(func(c engine.ResourceComparison) bool {
+				return c.ToDelete.ID() == id && s.matchCountToCreateByID[c.ToCreate.ID()] == 0
+			})
+			candidate, differences := "", ""
+			if ok {
+				candidate = fmt.Sprintf("%s (%.0f%%)", markdownResource(c.ToCreate), c.Similarity()*100)
+				differences = markdownDifferences(c)
+			}
+			rows = append(rows, row{"-" + id, fmt.Sprintf("| %s | delete | %s | %s |", markdownResource(r), candidate, differences)})
+		}
+	}
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].id < rows[j].id })
+
+	lines := []string{
+		"| Resource | Action | Closest candidate | Differences |",
+		"| --- | --- | --- | --- |",
+	}
+	for _, r := range rows {
+		lines = append(lines, r.line)
+	}
+
+	title := fmt.Sprintf("%s without a match", plural(len(rows), "resource"))
+
+	return markdownDetails(title, strings.Join(lines, "\n"))
+}
+
+// markdownDifferences lists the mismatching and missing attributes of a
+// comparison, with the value each resource has. Sensitive values are redacted.
+func markdownDifferences(c engine.ResourceComparison) string {
+	attrs := append(slices.Clone(c.MismatchingAttributes), c.MissingAttributes...)
+	sort.Strings(attrs)
+
+	var lines []string
+	for i, attr := range attrs {
+		if i == maxMarkdownDifferences {
+			lines = append(lines, fmt.Sprintf("and %d more", len(attrs)-i))
+			break
+		}
+
+		create, remove := "(sensitive)", "(sensitive)"
+		if !c.ToCreate.IsSensitive(attr) && !c.ToDelete.IsSensitive(attr) {
+			create = markdownCode(fmt.Sprintf("%#v", c.ToCreate.Attributes[attr]))
+			remove = markdownCode(fmt.Sprintf("%#v", c.ToDelete.Attributes[attr]))
+		}
+		if _, ok := c.ToCreate.Attributes[attr]; !ok {
+			create = "absent"
+		}
+
+		lines = append(lines, fmt.Sprintf("%s: %s → %s", markdownCode(attr), remove, create))
+	}
+
+	return strings.Join(lines, "<br>")
+}
+
+func markdownDetails(summary, content string) string {
+	return fmt.Sprintf("<details>\n<summary>%s</summary>\n\n%s\n\n</details>", summary, content)
+}
+
+func markdownResource(r engine.Resource) string {
+	if r.ModuleID == "." {
+		return markdownCode(r.Address) + " in current directory"
+	}
+	return fmt.Sprintf("%s in %s", markdownCode(r.Address), markdownCode(r.ModuleID))
+}
+
+// markdownCode formats text as inline code that is safe to use in a table.
+func markdownCode(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	if strings.Contains(text, "`") {
+		return "`` " + text + " ``"
+	}
+	return "`" + text + "`"
+}
+
+func htmlModule(module string) string {
+	if module == "." {
+		return "current directory"
+	}
+	return "<code>" + html.EscapeString(module) + "</code>"
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,69 @@
+package pretty
+
+import (
+	"testing"
+
+	"github.com/busser/tfautomv/pkg/engine"
+	"github.com/busser/tfautomv/pkg/golden"
+)
+
+func TestMarkdown(t *testing.T) {
+	moves, comparisons := testData()
+
+	rules := []string{"everything:random_pet:prefix"}
+	movedBlocks := "moved {\n  from = random_pet.alpha\n  to   = random_pet.alice\n}\n"
+
+	summarizer := NewSummarizer(moves, comparisons, 0)
+	golden.Equal(t, summarizer.Markdown(rules, movedBlocks))
+}
+
+func TestMarkdownWithConversion(t *testing.T) {
+	subnet := func(address, az string) engine.Resource {
+		return engine.Resource{
+			ModuleID:   ".",
+			Type:       "aws_subnet",
+			Address:    address,
+			Attributes: map[string]any{"availability_zone": az},
+		}
+	}
+
+	plan := engine.Plan{
+		ToCreate: []engine.Resource{
+			subnet(`aws_subnet.this["eu-west-1a"]`, "eu-west-1a"),
+			subnet(`aws_subnet.this["eu-west-1b"]`, "eu-west-1b"),
+		},
+		ToDelete: []engine.Resource{
+			subnet(`aws_subnet.this[0]`, "eu-west-1a"),
+			subnet(`aws_subnet.this[1]`, "eu-west-1b"),
+		},
+	}
+
+	comparisons := engine.CompareAll(plan, nil)
+	moves := engine.DetermineMoves(comparisons, engine.WithKeyHeuristics(true))
+
+	summarizer := NewSummarizer(moves, comparisons, 0)
+	golden.Equal(t, summarizer.Markdown(nil, ""))
+}
+
+func TestMarkdownRedactsSensitiveValues(t *testing.T) {
+	db := func(address, password, name string, sensitive []string) engine.Resource {
+		return engine.Resource{
+			ModuleID:   ".",
+			Type:       "aws_db_instance",
+			Address:    address,
+			Attributes: map[string]any{"password": password, "name": name},
+			Sensitive:  sensitive,
+		}
+	}
+
+	comparisons := []engine.ResourceComparison{
+		{
+			ToCreate:              db("aws_db_instance.new", "hunter2", "new|db", []string{"password"}),
+			ToDelete:              db("aws_db_instance.old", "swordfish", "old", nil),
+			MismatchingAttributes: []string{"password", "name"},
+		},
+	}
+
+	summarizer := NewSummarizer(nil, comparisons, 0)
+	golden.Equal(t, summarizer.Markdown(nil, ""))
+}
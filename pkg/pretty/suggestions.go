@@ -0,0 +1,59 @@
+package pretty
+
+import (
+	"strings"
+
+	"github.com/busser/tfautomv/pkg/engine"
+	"github.com/busser/tfautomv/pkg/engine/rules"
+)
+
+// RuleSuggestions presents rules the user could pass with --ignore, along
+// with the moves each would unlock, as found by rules.Suggestions.
+func RuleSuggestions(suggestions []rules.Suggestion) string {
+	if len(suggestions) == 0 {
+		return BoxSection("Suggested rules", "no rule would unlock more moves", "cyan")
+	}
+
+	headline := Colorf("[bold]%s[reset] would unlock more moves", plural(len(suggestions), "suggestion"))
+
+	var items []string
+	for _, s := range suggestions {
+		items = append(items, styledSuggestion(s))
+	}
+
+	return BoxSection("Suggested rules", headline+"\n"+BoxItems(items, "green"), "cyan")
+}
+
+func styledSuggestion(s rules.Suggestion) string {
+	var lines []string
+	for _, r := range s.Rules {
+		lines = append(lines, Colorf("[bold]--ignore=%q", r.String()))
+	}
+
+	lines = append(lines, "", Colorf("unlocks %s:", StyledNumMoves(len(s.Moves))))
+	for _, m := range s.Moves {
+		lines = append(lines, "  "+styledEngineMove(m))
+	}
+
+	if len(s.Lost) > 0 {
+		lines = append(lines, "", Colorf("[yellow][bold]warning:[reset] makes resources ambiguous, losing %s:", StyledNumMoves(len(s.Lost))))
+		for _, m := range s.Lost {
+			lines = append(lines, "  "+styledEngineMove(m))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func styledEngineMove(m engine.Move) string {
+	from := engine.Resource{ModuleID: m.SourceModule, Address: m.SourceAddress}
+	to := engine.Resource{ModuleID: m.DestinationModule, Address: m.DestinationAddress}
+	return Colorf("from %s to %s", styledResourceID(from), styledResourceID(to))
+}
+
+func styledResourceID(r engine.Resource) string {
+	if r.ModuleID == "." {
+		return Colorf("[bold]%s", r.Address)
+	}
+	return Colorf("[bold]%s[reset] in [bold]%s", r.Address, r.ModuleID)
+}
@@ -0,0 +1,61 @@
+package pretty
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/busser/tfautomv/pkg/engine"
+	"github.com/busser/tfautomv/pkg/engine/rules"
+	"github.com/busser/tfautomv/pkg/golden"
+)
+
+func TestRuleSuggestions(t *testing.T) {
+	move := func(fromModule, from, toModule, to string) engine.Move {
+		return engine.Move{SourceModule: fromModule, SourceAddress: from, DestinationModule: toModule, DestinationAddress: to}
+	}
+
+	tests := []struct {
+		name        string
+		suggestions []rules.Suggestion
+	}{
+		{
+			name: "none",
+		},
+		{
+			name: "several",
+			suggestions: []rules.Suggestion{
+				{
+					Rules: []engine.Rule{rules.MustParse("everything:aws_instance:tags.Team")},
+					Moves: []engine.Move{
+						move(".", "aws_instance.b_old", ".", "aws_instance.b_new"),
+						move(".", "aws_instance.c_old", "other", "aws_instance.c_new"),
+					},
+				},
+				{
+					Rules: []engine.Rule{
+						rules.MustParse("prefix:aws_s3_bucket:bucket:team-"),
+						rules.MustParse("whitespace:aws_s3_bucket:policy"),
+					},
+					Moves: []engine.Move{
+						move(".", "aws_s3_bucket.old", ".", "aws_s3_bucket.new"),
+					},
+					Lost: []engine.Move{
+						move(".", "aws_s3_bucket.logs_old", ".", "aws_s3_bucket.logs_new"),
+					},
+				},
+			},
+		},
+	}
+
+	for _, colorsEnabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("colors %v", colorsEnabled), func(t *testing.T) {
+			setupColors(t, colorsEnabled)
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					golden.Equal(t, RuleSuggestions(tt.suggestions))
+				})
+			}
+		})
+	}
+}
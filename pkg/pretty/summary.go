@@ -20,6 +20,8 @@ type Summarizer struct {
 	modulesWithMoves []string
 
 	// used to explain matches and non-matches
+	movedToCreateByID      map[string]bool
+	movedToDeleteByID      map[string]bool
 	resourcesToCreateByID  map[string]engine.Resource
 	resourcesToDeleteByID  map[string]engine.Resource
 	matchCountToCreateByID map[string]int
@@ -42,6 +44,13 @@ func NewSummarizer(moves []engine.Move, comparisons []engine.ResourceComparison,
 	modulesWithMoves = unique(modulesWithMoves)
 	sort.Strings(modulesWithMoves)
 
+	movedToCreateByID := make(map[string]bool)
+	movedToDeleteByID := make(map[string]bool)
+	for _, move := range moves {
+		movedToCreateByID[engine.Resource{ModuleID: move.DestinationModule, Address: move.DestinationAddress}.ID()] = true
+		movedToDeleteByID[engine.Resource{ModuleID: move.SourceModule, Address: move.SourceAddress}.ID()] = true
+	}
+
 	resourcesToCreateByID := make(map[string]engine.Resource)
 	resourcesToDeleteByID := make(map[string]engine.Resource)
 	matchCountToCreateByID := make(map[string]int)
@@ -63,6 +72,9 @@ func NewSummarizer(moves []engine.Move, comparisons []engine.ResourceComparison,
 
 		modulesWithMoves: modulesWithMoves,
 
+		movedToCreateByID: movedToCreateByID,
+		movedToDeleteByID: movedToDeleteByID,
+
 		resourcesToCreateByID: resourcesToCreateByID,
 		resourcesToDeleteByID: resourcesToDeleteByID,
 
@@ -72,9 +84,19 @@ func NewSummarizer(moves []engine.Move, comparisons []engine.ResourceComparison,
 }
 
 func (s *Summarizer) Summary() string {
-	parts := []string{
-		Colorf("tfautomv made %s and found %s", StyledNumComparisons(len(s.comparisons)), StyledNumMoves(len(s.moves))),
+	headline := Colorf("tfautomv made %s and found %s", StyledNumComparisons(len(s.comparisons)), StyledNumMoves(len(s.moves)))
+
+	var approximate int
+	for _, m := range s.moves {
+		if m.Approximate {
+			approximate++
+		}
 	}
+	if approximate > 0 {
+		headline += Colorf(", [yellow][bold]%d approximate", approximate)
+	}
+
+	parts := []string{headline}
 
 	var (
 		moves          = s.movesFound()
@@ -208,7 +230,7 @@ func (s *Summarizer) styledNumAttributes(n int) string {
 }
 
 func (s *Summarizer) styledMismatches(comp engine.ResourceComparison) string {
-	if len(comp.MismatchingAttributes) == 0 {
+	if comp.NumMismatches() == 0 {
 		return ""
 	}
 
@@ -217,7 +239,7 @@ func (s *Summarizer) styledMismatches(comp engine.ResourceComparison) string {
 	}
 
 	if s.verbosity < verbosityListAttributes {
-		return Colorf("%s%s %s", s.symbolCreate(), s.symbolDelete(), s.styledNumAttributes(len(comp.MismatchingAttributes)))
+		return Colorf("%s%s %s", s.symbolCreate(), s.symbolDelete(), s.styledNumAttributes(comp.NumMismatches()))
 	}
 
 	var lines []string
@@ -225,6 +247,9 @@ func (s *Summarizer) styledMismatches(comp engine.ResourceComparison) string {
 		lines = append(lines, Colorf("%s %s = %#v", s.symbolCreate(), attr, comp.ToCreate.Attributes[attr]))
 		lines = append(lines, Colorf("%s %s = %#v", s.symbolDelete(), attr, comp.ToDelete.Attributes[attr]))
 	}
+	for _, attr := range comp.MissingAttributes {
+		lines = append(lines, Colorf("%s %s = %#v (present before, absent after)", s.symbolDelete(), attr, comp.ToDelete.Attributes[attr]))
+	}
 
 	return strings.Join(lines, "\n")
 }
@@ -237,15 +262,61 @@ func (s *Summarizer) styledMove(m engine.Move) string {
 	lines = append(lines, Colorf("from %s", s.styledAddress(comp.ToDelete.Address)))
 	lines = append(lines, Colorf("to   %s", s.styledAddress(comp.ToCreate.Address)))
 
+	if m.Approximate {
+		lines = append(lines, "")
+		lines = append(lines, Colorf("[yellow][bold]approximate[reset]: resources are %s similar", StyledSimilarity(comp.Similarity())))
+	}
+
 	ignored := s.styledIgnored(comp)
 	if ignored != "" {
 		lines = append(lines, "")
 		lines = append(lines, ignored)
 	}
 
+	mismatches := s.styledMismatches(comp)
+	if mismatches != "" {
+		lines = append(lines, "")
+		lines = append(lines, mismatches)
+	}
+
+	alternatives := s.styledAlternatives(m, comp)
+	if alternatives != "" {
+		lines = append(lines, "")
+		lines = append(lines, alternatives)
+	}
+
 	return strings.Join(lines, "\n")
 }
 
+// styledAlternatives lists the other resources that matched either side of a
+// move, which the engine chose not to pair.
+func (s *Summarizer) styledAlternatives(m engine.Move, comp engine.ResourceComparison) string {
+	var alternatives []string
+	for _, c := range s.comparisons {
+		if !c.IsMatch() {
+			continue
+		}
+
+		switch {
+		case c.ToCreate.ID() == comp.ToCreate.ID() && c.ToDelete.ID() != comp.ToDelete.ID():
+			alternatives = append(alternatives, "  "+s.annotatedResource(c.ToDelete, s.annotationDelete()))
+		case c.ToDelete.ID() == comp.ToDelete.ID() && c.ToCreate.ID() != comp.ToCreate.ID():
+			alternatives = append(alternatives, "  "+s.annotatedResource(c.ToCreate, s.annotationCreate()))
+		}
+	}
+
+	if len(alternatives) == 0 {
+		return ""
+	}
+
+	header := "chosen over competing matches:"
+	if m.BestEffort {
+		header = Color("[yellow][bold]best effort[reset]: other pairings were equally good, chosen over competing matches:")
+	}
+
+	return strings.Join(append([]string{header}, alternatives...), "\n")
+}
+
 func StyledNumMoves(n int) string {
 	if n == 1 {
 		return Color("[bold][green]1 move")
@@ -254,6 +325,11 @@ func StyledNumMoves(n int) string {
 	return Colorf("[bold][green]%d moves", n)
 }
 
+// StyledSimilarity formats a similarity between 0 and 1 as a percentage.
+func StyledSimilarity(similarity float64) string {
+	return Colorf("[bold]%.0f%%", similarity*100)
+}
+
 func StyledNumComparisons(n int) string {
 	if n == 1 {
 		return Color("[bold][magenta]1 comparison")
@@ -262,19 +338,81 @@ func StyledNumComparisons(n int) string {
 	return Colorf("[bold][magenta]%d comparisons", n)
 }
 
+// styledConversion presents the moves of a resource converted from count to
+// for_each as a table, from old keys to new keys.
+func (s *Summarizer) styledConversion(c engine.Conversion) string {
+	lines := []string{
+		Colorf("convert %s from [bold]count[reset] to [bold]for_each", s.styledAddress(c.Address)),
+	}
+
+	var fromKeys, toKeys []string
+	fromWidth, toWidth := 0, 0
+	for _, m := range c.Moves {
+		fromKey := strings.TrimPrefix(m.SourceAddress, c.Address)
+		toKey := strings.TrimPrefix(m.DestinationAddress, c.Address)
+		fromKeys = append(fromKeys, fromKey)
+		toKeys = append(toKeys, toKey)
+		fromWidth = max(fromWidth, len(fromKey))
+		toWidth = max(toWidth, len(toKey))
+	}
+
+	for i, m := range c.Moves {
+		row := Colorf("  [bold]%-*s[reset] → [bold]%-*s[reset]", fromWidth, fromKeys[i], toWidth, toKeys[i])
+
+		var notes []string
+		if m.KeyAttribute != "" {
+			notes = append(notes, Colorf("[yellow]paired by [bold]%s", m.KeyAttribute))
+		}
+		switch n := s.findComparison(m).NumMismatches(); n {
+		case 0:
+		case 1:
+			notes = append(notes, "1 attribute differs")
+		default:
+			notes = append(notes, fmt.Sprintf("%d attributes differ", n))
+		}
+		if len(notes) > 0 {
+			row += "  " + strings.Join(notes, ", ")
+		}
+
+		lines = append(lines, strings.TrimRight(row, " "))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 func (s *Summarizer) styledMovesWithinModule(module string) string {
-	var styledMoves []string
+	var movesWithin []engine.Move
 	for _, move := range s.moves {
 		if move.SourceModule == module && move.DestinationModule == module {
-			styledMoves = append(styledMoves, s.styledMove(move))
+			movesWithin = append(movesWithin, move)
 		}
 	}
 
-	if len(styledMoves) == 0 {
+	if len(movesWithin) == 0 {
 		return ""
 	}
 
-	header := Colorf("%s within %s", StyledNumMoves(len(styledMoves)), s.StyledModule(module))
+	// Moves that are part of a count to for_each conversion are presented
+	// together, after the other moves.
+	conversions := engine.Conversions(movesWithin)
+	converted := make(map[engine.Move]bool)
+	for _, c := range conversions {
+		for _, m := range c.Moves {
+			converted[m] = true
+		}
+	}
+
+	var styledMoves []string
+	for _, move := range movesWithin {
+		if !converted[move] {
+			styledMoves = append(styledMoves, s.styledMove(move))
+		}
+	}
+	for _, c := range conversions {
+		styledMoves = append(styledMoves, s.styledConversion(c))
+	}
+
+	header := Colorf("%s within %s", StyledNumMoves(len(movesWithin)), s.StyledModule(module))
 
 	if s.verbosity < verbosityListMoves {
 		return header
@@ -420,13 +558,13 @@ func (s *Summarizer) multipleMatchExplanations() []string {
 	var explanations []string
 
 	for id, toCreate := range s.resourcesToCreateByID {
-		if s.matchCountToCreateByID[id] > 1 {
+		if s.matchCountToCreateByID[id] > 1 && !s.movedToCreateByID[id] {
 			explanations = append(explanations, s.styledMatchesForResourceToCreate(toCreate))
 		}
 	}
 
 	for id, toDelete := range s.resourcesToDeleteByID {
-		if s.matchCountToDeleteByID[id] > 1 {
+		if s.matchCountToDeleteByID[id] > 1 && !s.movedToDeleteByID[id] {
 			explanations = append(explanations, s.styledMatchesForResourceToDelete(toDelete))
 		}
 	}
@@ -492,13 +630,13 @@ func (s *Summarizer) noMatchExplanations() []string {
 	var explanations []string
 
 	for id, toCreate := range s.resourcesToCreateByID {
-		if s.matchCountToCreateByID[id] == 0 {
+		if s.matchCountToCreateByID[id] == 0 && !s.movedToCreateByID[id] {
 			explanations = append(explanations, s.styledNoMatchForResourceToCreate(toCreate))
 		}
 	}
 
 	for id, toDelete := range s.resourcesToDeleteByID {
-		if s.matchCountToDeleteByID[id] == 0 {
+		if s.matchCountToDeleteByID[id] == 0 && !s.movedToDeleteByID[id] {
 			explanations = append(explanations, s.styledNoMatchForResourceToDelete(toDelete))
 		}
 	}
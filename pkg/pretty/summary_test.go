@@ -47,6 +47,113 @@ func TestSummary(t *testing.T) {
 	}
 }
 
+func TestSummaryWithAssignment(t *testing.T) {
+	comparisons := testDataComparisons()
+
+	for _, colorsEnabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("colors %v", colorsEnabled), func(t *testing.T) {
+			setupColors(t, colorsEnabled)
+
+			for _, assignment := range []engine.Assignment{engine.AssignmentOptimal, engine.AssignmentBestEffort} {
+				t.Run(string(assignment), func(t *testing.T) {
+					moves := engine.DetermineMoves(comparisons, engine.WithAssignment(assignment))
+					summarizer := NewSummarizer(moves, comparisons, 1)
+					golden.Equal(t, summarizer.Summary())
+				})
+			}
+		})
+	}
+}
+
+func TestSummaryWithMinSimilarity(t *testing.T) {
+	comparisons := testDataComparisons()
+	moves := engine.DetermineMoves(comparisons, engine.WithMinSimilarity(0.6))
+
+	for _, colorsEnabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("colors %v", colorsEnabled), func(t *testing.T) {
+			setupColors(t, colorsEnabled)
+
+			summarizer := NewSummarizer(moves, comparisons, 3)
+			golden.Equal(t, summarizer.Summary())
+		})
+	}
+}
+
+func TestSummaryWithConversion(t *testing.T) {
+	subnet := func(address, az, name string) engine.Resource {
+		return engine.Resource{
+			ModuleID: ".",
+			Type:     "aws_subnet",
+			Address:  address,
+			Attributes: map[string]any{
+				"availability_zone": az,
+				"name":              name,
+			},
+		}
+	}
+
+	plan := engine.Plan{
+		ToCreate: []engine.Resource{
+			subnet(`aws_subnet.this["eu-west-1a"]`, "eu-west-1a", "subnet-0"),
+			subnet(`aws_subnet.this["eu-west-1b"]`, "eu-west-1b", "subnet-b"),
+			subnet(`aws_subnet.this["eu-west-1c"]`, "eu-west-1c", "subnet-c"),
+		},
+		ToDelete: []engine.Resource{
+			subnet(`aws_subnet.this[0]`, "eu-west-1a", "subnet-0"),
+			subnet(`aws_subnet.this[1]`, "eu-west-1b", "subnet-1"),
+			subnet(`aws_subnet.this[2]`, "eu-west-1c", "subnet-2"),
+		},
+	}
+
+	comparisons := engine.CompareAll(plan, nil)
+	moves := engine.DetermineMoves(comparisons, engine.WithKeyHeuristics(true))
+
+	for _, colorsEnabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("colors %v", colorsEnabled), func(t *testing.T) {
+			setupColors(t, colorsEnabled)
+
+			summarizer := NewSummarizer(moves, comparisons, 1)
+			golden.Equal(t, summarizer.Summary())
+		})
+	}
+}
+
+func TestSummaryStrict(t *testing.T) {
+	instance := func(address string, attributes map[string]any) engine.Resource {
+		return engine.Resource{
+			ModuleID:   ".",
+			Type:       "aws_instance",
+			Address:    address,
+			Attributes: attributes,
+		}
+	}
+
+	plan := engine.Plan{
+		ToCreate: []engine.Resource{
+			instance("aws_instance.new", map[string]any{"ami": "ami-1", "tags.Name": "web"}),
+		},
+		ToDelete: []engine.Resource{
+			instance("aws_instance.old", map[string]any{"ami": "ami-1", "tags.Name": "web", "tags.Owner": "alice"}),
+		},
+	}
+
+	comparisons := engine.CompareAll(plan, nil, engine.WithStrict(true))
+	moves := engine.DetermineMoves(comparisons)
+
+	for _, colorsEnabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("colors %v", colorsEnabled), func(t *testing.T) {
+			setupColors(t, colorsEnabled)
+
+			for _, verbosity := range []int{2, 3} {
+				t.Run(fmt.Sprintf("verbosity %d", verbosity), func(t *testing.T) {
+					summarizer := NewSummarizer(moves, comparisons, verbosity)
+					golden.Equal(t, summarizer.Summary())
+				})
+			}
+		})
+	}
+}
+
 func testData() ([]engine.Move, []engine.ResourceComparison) {
 	return testDataMoves(), testDataComparisons()
 }
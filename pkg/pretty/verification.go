@@ -0,0 +1,47 @@
+package pretty
+
+import (
+	"strings"
+
+	"github.com/busser/tfautomv/pkg/terraform"
+)
+
+// Verification presents what Terraform plans for each moved resource once
+// the moves are applied, as found by terraform.VerifyMoves.
+func Verification(verifications []terraform.Verification) string {
+	failed := 0
+	for _, v := range verifications {
+		if !v.OK() {
+			failed++
+		}
+	}
+
+	headline := Colorf("verified %s", StyledNumMoves(len(verifications)))
+	color := "green"
+	if failed > 0 {
+		headline += Colorf(", [red][bold]%d failed", failed)
+		color = "red"
+	}
+
+	lines := []string{headline, ""}
+	for _, v := range verifications {
+		lines = append(lines, styledVerification(v))
+	}
+
+	return BoxSection("Verification", strings.Join(lines, "\n"), color)
+}
+
+func styledVerification(v terraform.Verification) string {
+	move := Colorf("[bold]%s[reset] to [bold]%s[reset]", v.Move.FromAddress, v.Move.ToAddress)
+
+	switch v.Status {
+	case terraform.VerifiedNoOp:
+		return Colorf("[green][bold]✓[reset] %s: no changes", move)
+	case terraform.VerifiedUpdate:
+		return Colorf("[yellow][bold]~[reset] %s: updated in place (%s)", move, strings.Join(v.Attributes, ", "))
+	case terraform.VerifiedReplace:
+		return Colorf("[red][bold]✗[reset] %s: still [red][bold]replaced", move)
+	default:
+		return Colorf("[red][bold]✗[reset] %s: not found in the plan", move)
+	}
+}
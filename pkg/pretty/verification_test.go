@@ -0,0 +1,48 @@
+package pretty
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/busser/tfautomv/pkg/golden"
+	"github.com/busser/tfautomv/pkg/terraform"
+)
+
+func TestVerification(t *testing.T) {
+	move := func(from, to string) terraform.Move {
+		return terraform.Move{FromWorkdir: ".", ToWorkdir: ".", FromAddress: from, ToAddress: to}
+	}
+
+	tests := []struct {
+		name          string
+		verifications []terraform.Verification
+	}{
+		{
+			name: "all verified",
+			verifications: []terraform.Verification{
+				{Move: move("aws_instance.a", "aws_instance.b"), Status: terraform.VerifiedNoOp},
+				{Move: move("aws_instance.c", "aws_instance.d"), Status: terraform.VerifiedUpdate, Attributes: []string{"name", "tags"}},
+			},
+		},
+		{
+			name: "some failed",
+			verifications: []terraform.Verification{
+				{Move: move("aws_instance.a", "aws_instance.b"), Status: terraform.VerifiedNoOp},
+				{Move: move("aws_instance.c", "aws_instance.d"), Status: terraform.VerifiedReplace},
+				{Move: move("module.a", "module.b"), Status: terraform.VerifiedMissing},
+			},
+		},
+	}
+
+	for _, colorsEnabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("colors %v", colorsEnabled), func(t *testing.T) {
+			setupColors(t, colorsEnabled)
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					golden.Equal(t, Verification(tt.verifications))
+				})
+			}
+		})
+	}
+}
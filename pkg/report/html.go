@@ -0,0 +1,141 @@
+package report
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+
+	"github.com/busser/tfautomv/pkg/engine"
+)
+
+//go:embed html
+var htmlFiles embed.FS
+
+var htmlTemplate = template.Must(template.ParseFS(htmlFiles, "html/report.html.tmpl"))
+
+// htmlData is what the HTML report's script renders. Attribute values are
+// formatted beforehand, so that sensitive values never reach the file.
+type htmlData struct {
+	TfautomvVersion string           `json:"tfautomv_version"`
+	Moves           int              `json:"moves"`
+	Resources       []htmlResource   `json:"resources"`
+	Comparisons     []htmlComparison `json:"comparisons"`
+}
+
+type htmlResource struct {
+	Module     string            `json:"module"`
+	Type       string            `json:"type"`
+	Address    string            `json:"address"`
+	Action     string            `json:"action"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+type htmlComparison struct {
+	// Indices of the resources in htmlData.Resources.
+	ToCreate int `json:"to_create"`
+	ToDelete int `json:"to_delete"`
+
+	Moved      bool    `json:"moved"`
+	Match      bool    `json:"match"`
+	Similarity float64 `json:"similarity"`
+
+	Matching    []string `json:"matching"`
+	Mismatching []string `json:"mismatching"`
+	Ignored     []string `json:"ignored"`
+	Missing     []string `json:"missing"`
+}
+
+// WriteHTML writes a self-contained HTML page to explore the engine's
+// findings on the given plan: every comparison between resources to create and
+// delete, which can be filtered by type and module, and a side-by-side view of
+// the attributes of any pair. Values Terraform marks as sensitive are redacted.
+func WriteHTML(w io.Writer, plan engine.Plan, moves []engine.Move, comparisons []engine.ResourceComparison, tfautomvVersion string) error {
+	data := htmlData{
+		TfautomvVersion: tfautomvVersion,
+		Moves:           len(moves),
+		Resources:       []htmlResource{},
+		Comparisons:     []htmlComparison{},
+	}
+
+	moved := make(map[[2]string]bool)
+	for _, m := range moves {
+		from := engine.Resource{ModuleID: m.SourceModule, Address: m.SourceAddress}.ID()
+		to := engine.Resource{ModuleID: m.DestinationModule, Address: m.DestinationAddress}.ID()
+		moved[[2]string{to, from}] = true
+	}
+
+	indices := make(map[string]int)
+	index := func(r engine.Resource, action string) int {
+		key := action + ":" + r.ID()
+		if i, ok := indices[key]; ok {
+			return i
+		}
+
+		attributes := make(map[string]string, len(r.Attributes))
+		for k, v := range r.Attributes {
+			if r.IsSensitive(k) {
+				attributes[k] = "(sensitive)"
+			} else {
+				attributes[k] = fmt.Sprintf("%#v", v)
+			}
+		}
+
+		indices[key] = len(data.Resources)
+		data.Resources = append(data.Resources, htmlResource{
+			Module:     r.ModuleID,
+			Type:       r.Type,
+			Address:    r.Address,
+			Action:     action,
+			Attributes: attributes,
+		})
+		return indices[key]
+	}
+
+	// Resources are taken from the plan rather than from comparisons, since
+	// a resource without any other resource of its type is never compared.
+	for _, r := range plan.ToCreate {
+		index(r, "create")
+	}
+	for _, r := range plan.ToDelete {
+		index(r, "delete")
+	}
+
+	for _, c := range comparisons {
+		data.Comparisons = append(data.Comparisons, htmlComparison{
+			ToCreate:    index(c.ToCreate, "create"),
+			ToDelete:    index(c.ToDelete, "delete"),
+			Moved:       moved[[2]string{c.ToCreate.ID(), c.ToDelete.ID()}],
+			Match:       c.IsMatch(),
+			Similarity:  c.Similarity(),
+			Matching:    sorted(c.MatchingAttributes),
+			Mismatching: sorted(c.MismatchingAttributes),
+			Ignored:     sorted(c.IgnoredAttributes),
+			Missing:     sorted(c.MissingAttributes),
+		})
+	}
+
+	sort.SliceStable(data.Comparisons, func(i, j int) bool {
+		return data.Comparisons[i].Similarity > data.Comparisons[j].Similarity
+	})
+
+	css, err := htmlFiles.ReadFile("html/report.css")
+	if err != nil {
+		return err
+	}
+	js, err := htmlFiles.ReadFile("html/report.js")
+	if err != nil {
+		return err
+	}
+
+	return htmlTemplate.Execute(w, struct {
+		Data htmlData
+		CSS  template.CSS
+		JS   template.JS
+	}{
+		Data: data,
+		CSS:  template.CSS(css),
+		JS:   template.JS(js),
+	})
+}
@@ -0,0 +1,146 @@
+(function () {
+  "use strict";
+
+  const resources = DATA.resources;
+  const comparisons = DATA.comparisons;
+
+  const byId = (id) => document.getElementById(id);
+
+  function text(tag, content, className) {
+    const el = document.createElement(tag);
+    el.textContent = content;
+    if (className) {
+      el.className = className;
+    }
+    return el;
+  }
+
+  function moduleName(module) {
+    return module === "." ? "current directory" : module;
+  }
+
+  function resourceCell(r) {
+    const td = document.createElement("td");
+    td.appendChild(text("code", r.address));
+    td.appendChild(document.createElement("br"));
+    td.appendChild(text("span", moduleName(r.module), "module"));
+    return td;
+  }
+
+  function status(c) {
+    if (c.moved) {
+      return "moved";
+    }
+    return c.match ? "match" : "mismatch";
+  }
+
+  function fillSelect(select, values) {
+    [...new Set(values)].sort().forEach((v) => {
+      const option = text("option", moduleName(v));
+      option.value = v;
+      select.appendChild(option);
+    });
+  }
+
+  function matches(c) {
+    const create = resources[c.to_create];
+    const del = resources[c.to_delete];
+    const type = byId("filter-type").value;
+    const module = byId("filter-module").value;
+    const wanted = byId("filter-status").value;
+    const address = byId("filter-address").value.trim();
+
+    if (type && create.type !== type && del.type !== type) {
+      return false;
+    }
+    if (module && create.module !== module && del.module !== module) {
+      return false;
+    }
+    if (wanted === "moved" && !c.moved) {
+      return false;
+    }
+    if (wanted === "match" && !c.match) {
+      return false;
+    }
+    if (wanted === "mismatch" && c.match) {
+      return false;
+    }
+    if (address && !create.address.includes(address) && !del.address.includes(address)) {
+      return false;
+    }
+    return true;
+  }
+
+  function showDetails(c) {
+    const create = resources[c.to_create];
+    const del = resources[c.to_delete];
+    const details = byId("details");
+    details.replaceChildren();
+
+    details.appendChild(text("h2", del.address + " → " + create.address));
+
+    const kinds = {};
+    c.matching.forEach((a) => (kinds[a] = "matching"));
+    c.mismatching.forEach((a) => (kinds[a] = "mismatching"));
+    c.ignored.forEach((a) => (kinds[a] = "ignored"));
+    c.missing.forEach((a) => (kinds[a] = "missing"));
+
+    const keys = [...new Set([...Object.keys(create.attributes), ...Object.keys(del.attributes)])].sort();
+
+    const table = document.createElement("table");
+    const head = document.createElement("tr");
+    ["Attribute", "To delete", "To create", "Comparison"].forEach((h) => head.appendChild(text("th", h)));
+    table.appendChild(head);
+
+    keys.forEach((key) => {
+      const kind = kinds[key] || "not compared";
+      const row = document.createElement("tr");
+      row.className = kinds[key] || "";
+      row.appendChild(text("td", key, "value"));
+      row.appendChild(text("td", key in del.attributes ? del.attributes[key] : "", "value"));
+      row.appendChild(text("td", key in create.attributes ? create.attributes[key] : "", "value"));
+      row.appendChild(text("td", kind));
+      table.appendChild(row);
+    });
+
+    details.appendChild(table);
+  }
+
+  function render() {
+    const tbody = byId("comparison-rows");
+    tbody.replaceChildren();
+
+    let shown = 0;
+    comparisons.forEach((c) => {
+      if (!matches(c)) {
+        return;
+      }
+      shown++;
+
+      const row = document.createElement("tr");
+      row.appendChild(resourceCell(resources[c.to_delete]));
+      row.appendChild(resourceCell(resources[c.to_create]));
+      row.appendChild(text("td", status(c), "status-" + status(c)));
+      row.appendChild(text("td", Math.round(c.similarity * 100) + "%"));
+      row.addEventListener("click", () => {
+        tbody.querySelectorAll(".selected").forEach((r) => r.classList.remove("selected"));
+        row.classList.add("selected");
+        showDetails(c);
+      });
+      tbody.appendChild(row);
+    });
+
+    byId("summary").textContent =
+      "tfautomv " + DATA.tfautomv_version + " compared " + resources.length + " resources in " +
+      comparisons.length + " comparisons and found " + DATA.moves + " moves. Showing " + shown + " comparisons.";
+  }
+
+  fillSelect(byId("filter-type"), resources.map((r) => r.type));
+  fillSelect(byId("filter-module"), resources.map((r) => r.module));
+
+  ["filter-type", "filter-module", "filter-status", "filter-address"].forEach((id) =>
+    byId(id).addEventListener("input", render)
+  );
+
+  render();
+})();
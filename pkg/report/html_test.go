@@ -0,0 +1,118 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/busser/tfautomv/pkg/engine"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteHTML(t *testing.T) {
+	db := func(address, password string) engine.Resource {
+		return engine.Resource{
+			ModuleID:   ".",
+			Type:       "aws_db_instance",
+			Address:    address,
+			Attributes: map[string]any{"name": "</script><script>alert(1)</script>", "password": password},
+			Sensitive:  []string{"password"},
+		}
+	}
+
+	comparisons := []engine.ResourceComparison{
+		{
+			ToCreate:              db("aws_db_instance.new", "hunter2"),
+			ToDelete:              db("aws_db_instance.old", "swordfish"),
+			MatchingAttributes:    []string{"name"},
+			MismatchingAttributes: []string{"password"},
+		},
+	}
+
+	lone := engine.Resource{ModuleID: "other", Type: "aws_s3_bucket", Address: "aws_s3_bucket.lone", Attributes: map[string]any{}}
+	plan := engine.Plan{
+		ToCreate: []engine.Resource{comparisons[0].ToCreate, lone},
+		ToDelete: []engine.Resource{comparisons[0].ToDelete},
+	}
+	moves := []engine.Move{
+		{SourceModule: ".", SourceAddress: "aws_db_instance.old", DestinationModule: ".", DestinationAddress: "aws_db_instance.new"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, plan, moves, comparisons, "v1.2.3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	page := buf.String()
+
+	for _, secret := range []string{"hunter2", "swordfish"} {
+		if strings.Contains(page, secret) {
+			t.Errorf("report contains sensitive value %q", secret)
+		}
+	}
+	if strings.Contains(page, "<script>alert(1)") {
+		t.Errorf("report contains unescaped attribute value")
+	}
+
+	// The report is self-contained: it loads nothing from elsewhere.
+	for _, external := range []string{"<link", "src="} {
+		if strings.Contains(page, external) {
+			t.Errorf("report references an external resource with %q", external)
+		}
+	}
+
+	start := strings.Index(page, "const DATA = ")
+	end := strings.Index(page[start:], ";\n")
+	if start < 0 || end < 0 {
+		t.Fatalf("report does not contain data")
+	}
+
+	var data htmlData
+	if err := json.Unmarshal([]byte(page[start+len("const DATA = "):start+end]), &data); err != nil {
+		t.Fatalf("failed to parse data: %v", err)
+	}
+
+	want := htmlData{
+		TfautomvVersion: "v1.2.3",
+		Moves:           1,
+		Resources: []htmlResource{
+			{
+				Module:     ".",
+				Type:       "aws_db_instance",
+				Address:    "aws_db_instance.new",
+				Action:     "create",
+				Attributes: map[string]string{"name": `"</script><script>alert(1)</script>"`, "password": "(sensitive)"},
+			},
+			{
+				Module:     "other",
+				Type:       "aws_s3_bucket",
+				Address:    "aws_s3_bucket.lone",
+				Action:     "create",
+				Attributes: map[string]string{},
+			},
+			{
+				Module:     ".",
+				Type:       "aws_db_instance",
+				Address:    "aws_db_instance.old",
+				Action:     "delete",
+				Attributes: map[string]string{"name": `"</script><script>alert(1)</script>"`, "password": "(sensitive)"},
+			},
+		},
+		Comparisons: []htmlComparison{
+			{
+				ToCreate:    0,
+				ToDelete:    2,
+				Moved:       true,
+				Similarity:  0.5,
+				Matching:    []string{"name"},
+				Mismatching: []string{"password"},
+				Ignored:     []string{},
+				Missing:     []string{},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, data); diff != "" {
+		t.Errorf("data mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,222 @@
+// Package report serializes the findings of the tfautomv engine for other
+// tools to consume.
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/busser/tfautomv/pkg/engine"
+)
+
+// FormatVersion is the version of the report's schema. The minor version is
+// incremented when fields are added, and the major version when fields are
+// removed or change meaning. Consumers should reject reports with a major
+// version they do not know.
+const FormatVersion = "1"
+
+// A Report describes the moves tfautomv found, and the comparisons it made to
+// find them.
+type Report struct {
+	FormatVersion   string `json:"format_version"`
+	TfautomvVersion string `json:"tfautomv_version"`
+
+	// The moves tfautomv found, one per resource instance.
+	Moves []Move `json:"moves"`
+
+	// The moves tfautomv writes as moved blocks or commands, where the moves
+	// of every resource in a module instance are collapsed into a single move
+	// of the module instance.
+	EmittedMoves []EmittedMove `json:"emitted_moves"`
+
+	// Every comparison between a resource to create and a resource to delete,
+	// whether the resources match or not.
+	Comparisons []Comparison `json:"comparisons"`
+
+	// Resources without any match, that tfautomv did not move.
+	Unmatched Unmatched `json:"unmatched"`
+
+	// Groups of resources that match each other ambiguously, that tfautomv
+	// did not move.
+	Ambiguous []AmbiguousGroup `json:"ambiguous"`
+}
+
+// A Resource identifies a resource Terraform plans to create or delete.
+type Resource struct {
+	// The working directory of the module the resource belongs to.
+	Module  string `json:"module"`
+	Type    string `json:"type"`
+	Address string `json:"address"`
+}
+
+// A Move is a move tfautomv found.
+type Move struct {
+	From Resource `json:"from"`
+	To   Resource `json:"to"`
+
+	// Whether the move was picked among other, equally good pairings.
+	BestEffort bool `json:"best_effort"`
+	// Whether the resources do not match, but are similar enough.
+	Approximate bool `json:"approximate"`
+	// The attribute the resources were paired by, when converting from count
+	// to for_each. Empty otherwise.
+	KeyAttribute string `json:"key_attribute"`
+}
+
+// An EmittedMove is a move tfautomv writes, of a resource instance or of a
+// whole module instance.
+type EmittedMove struct {
+	From Address `json:"from"`
+	To   Address `json:"to"`
+}
+
+// An Address identifies a resource instance or a module instance.
+type Address struct {
+	// The working directory of the module the address belongs to.
+	Module  string `json:"module"`
+	Address string `json:"address"`
+}
+
+// A Comparison is a comparison between a resource to create and a resource to
+// delete.
+type Comparison struct {
+	ToCreate Resource `json:"to_create"`
+	ToDelete Resource `json:"to_delete"`
+
+	Match      bool    `json:"match"`
+	Similarity float64 `json:"similarity"`
+
+	MatchingAttributes    []string `json:"matching_attributes"`
+	MismatchingAttributes []string `json:"mismatching_attributes"`
+	IgnoredAttributes     []string `json:"ignored_attributes"`
+	MissingAttributes     []string `json:"missing_attributes"`
+}
+
+// Unmatched lists the resources without any match.
+type Unmatched struct {
+	ToCreate []Resource `json:"to_create"`
+	ToDelete []Resource `json:"to_delete"`
+}
+
+// An AmbiguousGroup is a group of resources that match each other
+// ambiguously.
+type AmbiguousGroup struct {
+	ToCreate []Resource `json:"to_create"`
+	ToDelete []Resource `json:"to_delete"`
+}
+
+// New builds a report from the engine's findings on the given plan. The
+// emitted moves are those tfautomv writes, after collapsing moves with
+// engine.CollapseModuleMoves.
+func New(plan engine.Plan, moves, emittedMoves []engine.Move, comparisons []engine.ResourceComparison, tfautomvVersion string) Report {
+	r := Report{
+		FormatVersion:   FormatVersion,
+		TfautomvVersion: tfautomvVersion,
+		Moves:           []Move{},
+		EmittedMoves:    []EmittedMove{},
+		Comparisons:     []Comparison{},
+		Unmatched:       Unmatched{ToCreate: []Resource{}, ToDelete: []Resource{}},
+		Ambiguous:       []AmbiguousGroup{},
+	}
+
+	// Resources are taken from the plan rather than from comparisons, since
+	// a resource without any other resource of its type is never compared.
+	resourcesByID := make(map[string]engine.Resource)
+	for _, res := range plan.ToCreate {
+		resourcesByID["+"+res.ID()] = res
+	}
+	for _, res := range plan.ToDelete {
+		resourcesByID["-"+res.ID()] = res
+	}
+
+	for _, m := range moves {
+		from := resourcesByID["-"+engine.Resource{ModuleID: m.SourceModule, Address: m.SourceAddress}.ID()]
+		to := resourcesByID["+"+engine.Resource{ModuleID: m.DestinationModule, Address: m.DestinationAddress}.ID()]
+
+		r.Moves = append(r.Moves, Move{
+			From:         Resource{Module: m.SourceModule, Type: from.Type, Address: m.SourceAddress},
+			To:           Resource{Module: m.DestinationModule, Type: to.Type, Address: m.DestinationAddress},
+			BestEffort:   m.BestEffort,
+			Approximate:  m.Approximate,
+			KeyAttribute: m.KeyAttribute,
+		})
+	}
+
+	for _, m := range emittedMoves {
+		r.EmittedMoves = append(r.EmittedMoves, EmittedMove{
+			From: Address{Module: m.SourceModule, Address: m.SourceAddress},
+			To:   Address{Module: m.DestinationModule, Address: m.DestinationAddress},
+		})
+	}
+
+	matched := make(map[string]bool)
+	for _, c := range comparisons {
+		r.Comparisons = append(r.Comparisons, Comparison{
+			ToCreate:              resource(c.ToCreate),
+			ToDelete:              resource(c.ToDelete),
+			Match:                 c.IsMatch(),
+			Similarity:            c.Similarity(),
+			MatchingAttributes:    sorted(c.MatchingAttributes),
+			MismatchingAttributes: sorted(c.MismatchingAttributes),
+			IgnoredAttributes:     sorted(c.IgnoredAttributes),
+			MissingAttributes:     sorted(c.MissingAttributes),
+		})
+
+		if c.IsMatch() {
+			matched["+"+c.ToCreate.ID()] = true
+			matched["-"+c.ToDelete.ID()] = true
+		}
+	}
+	for _, m := range moves {
+		matched["+"+engine.Resource{ModuleID: m.DestinationModule, Address: m.DestinationAddress}.ID()] = true
+		matched["-"+engine.Resource{ModuleID: m.SourceModule, Address: m.SourceAddress}.ID()] = true
+	}
+
+	var ids []string
+	for id := range resourcesByID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if matched[id] {
+			continue
+		}
+		if id[0] == '+' {
+			r.Unmatched.ToCreate = append(r.Unmatched.ToCreate, resource(resourcesByID[id]))
+		} else {
+			r.Unmatched.ToDelete = append(r.Unmatched.ToDelete, resource(resourcesByID[id]))
+		}
+	}
+
+	for _, g := range engine.AmbiguousGroups(comparisons, moves) {
+		group := AmbiguousGroup{}
+		for _, res := range g.ToCreate {
+			group.ToCreate = append(group.ToCreate, resource(res))
+		}
+		for _, res := range g.ToDelete {
+			group.ToDelete = append(group.ToDelete, resource(res))
+		}
+		r.Ambiguous = append(r.Ambiguous, group)
+	}
+
+	return r
+}
+
+// Write writes the report as indented JSON.
+func (r Report) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func resource(r engine.Resource) Resource {
+	return Resource{Module: r.ModuleID, Type: r.Type, Address: r.Address}
+}
+
+func sorted(s []string) []string {
+	s = append([]string{}, s...)
+	sort.Strings(s)
+	return s
+}
@@ -0,0 +1,85 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/busser/tfautomv/pkg/engine"
+	"github.com/busser/tfautomv/pkg/golden"
+)
+
+func TestReport(t *testing.T) {
+	resource := func(module, address string) engine.Resource {
+		return engine.Resource{ModuleID: module, Type: "aws_instance", Address: address}
+	}
+
+	comparisons := []engine.ResourceComparison{
+		{
+			ToCreate:           resource(".", "aws_instance.new"),
+			ToDelete:           resource(".", "aws_instance.old"),
+			MatchingAttributes: []string{"name", "ami"},
+			IgnoredAttributes:  []string{"tags.Name"},
+		},
+		{
+			ToCreate:              resource(".", "aws_instance.new"),
+			ToDelete:              resource(".", "aws_instance.unmatched"),
+			MatchingAttributes:    []string{"ami"},
+			MismatchingAttributes: []string{"name"},
+		},
+		{
+			ToCreate:           resource("other", "aws_instance.twin_a"),
+			ToDelete:           resource(".", "aws_instance.twin"),
+			MatchingAttributes: []string{"ami"},
+		},
+		{
+			ToCreate:           resource("other", "aws_instance.twin_b"),
+			ToDelete:           resource(".", "aws_instance.twin"),
+			MatchingAttributes: []string{"ami"},
+		},
+	}
+
+	plan := engine.Plan{
+		ToCreate: []engine.Resource{
+			resource(".", "aws_instance.new"),
+			resource("other", "aws_instance.twin_a"),
+			resource("other", "aws_instance.twin_b"),
+			{ModuleID: ".", Type: "aws_s3_bucket", Address: "aws_s3_bucket.lone"},
+		},
+		ToDelete: []engine.Resource{
+			resource(".", "aws_instance.old"),
+			resource(".", "aws_instance.unmatched"),
+			resource(".", "aws_instance.twin"),
+			{ModuleID: ".", Type: "aws_iam_role", Address: "aws_iam_role.lone"},
+		},
+	}
+
+	moves := []engine.Move{
+		{
+			SourceModule:       ".",
+			SourceAddress:      "aws_instance.old",
+			DestinationModule:  ".",
+			DestinationAddress: "aws_instance.new",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := New(plan, moves, moves, comparisons, "v1.2.3").Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !json.Valid(buf.Bytes()) {
+		t.Fatalf("report is not valid JSON")
+	}
+
+	golden.Equal(t, buf.String())
+}
+
+func TestReportEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New(engine.Plan{}, nil, nil, nil, "v1.2.3").Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	golden.Equal(t, buf.String())
+}
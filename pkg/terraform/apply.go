@@ -0,0 +1,261 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+)
+
+// ApplyMoves performs the given moves directly on the state of each working
+// directory involved, with the same commands WriteMoveCommands would write.
+//
+// Before anything else, the state of each working directory is pulled and
+// saved to a timestamped backup file within that directory. The moves are
+// performed on local copies of those states, which are then pushed back.
+// Before each push, the remote state's lineage and serial are checked against
+// the backup, so that changes made by someone else in the meantime are not
+// overwritten. If any step fails, every state already pushed is restored from
+// its backup.
+//
+// ApplyMoves returns the paths to the backup files, which are not deleted
+// automatically.
+func ApplyMoves(ctx context.Context, moves []Move, opts ...Option) ([]string, error) {
+	var settings settings
+
+	settings.apply(append(defaultOptions(), opts...))
+
+	err := settings.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	return applyMoves(ctx, tfexecRunner{terraformBin: settings.terraformBin}, moves, time.Now())
+}
+
+// A stateRunner runs the Terraform commands ApplyMoves relies on.
+type stateRunner interface {
+	// pull returns the raw state of the given working directory.
+	pull(ctx context.Context, workdir string) ([]byte, error)
+	// push replaces the state of the given working directory with the state
+	// in the given file.
+	push(ctx context.Context, workdir, path string, force bool) error
+	// mv moves a resource from one local state file to another. Both paths
+	// are the same when the move is within a single working directory.
+	mv(ctx context.Context, from, to, state, stateOut string) error
+}
+
+// stateMeta identifies a version of a Terraform state.
+type stateMeta struct {
+	Lineage string `json:"lineage"`
+	Serial  int64  `json:"serial"`
+}
+
+// parseStateMeta reads the lineage and serial of the given raw state. Working
+// directories without a state yet have empty raw states, which are read as
+// fresh states: no lineage and a serial of 0.
+func parseStateMeta(raw []byte) (stateMeta, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return stateMeta{}, nil
+	}
+
+	var meta stateMeta
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return stateMeta{}, fmt.Errorf("failed to parse state: %w", err)
+	}
+	return meta, nil
+}
+
+const (
+	localCopyFileName = ".tfautomv.tfstate"
+	backupTimeFormat  = "20060102T150405"
+)
+
+func backupFileName(now time.Time) string {
+	return fmt.Sprintf(".tfautomv-backup-%s.tfstate", now.Format(backupTimeFormat))
+}
+
+func applyMoves(ctx context.Context, r stateRunner, moves []Move, now time.Time) (backups []string, err error) {
+	if len(moves) == 0 {
+		return nil, nil
+	}
+
+	var workdirs []string
+	for _, m := range moves {
+		workdirs = append(workdirs, m.FromWorkdir, m.ToWorkdir)
+	}
+	workdirs = unique(workdirs)
+	sort.Strings(workdirs)
+
+	type workdirState struct {
+		meta      stateMeta
+		backup    string
+		localCopy string
+	}
+	states := make(map[string]workdirState)
+
+	defer func() {
+		for _, s := range states {
+			os.Remove(s.localCopy)
+		}
+	}()
+
+	// Back up every state before touching any of them.
+
+	for _, workdir := range workdirs {
+		raw, err := r.pull(ctx, workdir)
+		if err != nil {
+			return backups, fmt.Errorf("failed to pull state of %q: %w", workdir, err)
+		}
+
+		meta, err := parseStateMeta(raw)
+		if err != nil {
+			return backups, fmt.Errorf("failed to read state of %q: %w", workdir, err)
+		}
+
+		// States can contain secrets, so only the user may read the files.
+		backup := filepath.Join(workdir, backupFileName(now))
+		if err := os.WriteFile(backup, raw, 0600); err != nil {
+			return backups, fmt.Errorf("failed to back up state of %q: %w", workdir, err)
+		}
+		backups = append(backups, backup)
+
+		localCopy := filepath.Join(workdir, localCopyFileName)
+		if err := os.WriteFile(localCopy, raw, 0600); err != nil {
+			return backups, fmt.Errorf("failed to copy state of %q: %w", workdir, err)
+		}
+
+		states[workdir] = workdirState{meta: meta, backup: backup, localCopy: localCopy}
+	}
+
+	// Perform the moves on the local copies. Nothing is pushed yet, so a
+	// failure here leaves the real states untouched.
+
+	for _, m := range orderMoves(moves) {
+		err := r.mv(ctx, m.FromAddress, m.ToAddress, states[m.FromWorkdir].localCopy, states[m.ToWorkdir].localCopy)
+		if err != nil {
+			return backups, fmt.Errorf("failed to move %s to %s: %w", m.FromAddress, m.ToAddress, err)
+		}
+	}
+
+	// Push the local copies, restoring every state already pushed if any
+	// push fails.
+
+	var pushed []string
+	for _, workdir := range workdirs {
+		err := pushState(ctx, r, workdir, states[workdir].localCopy, states[workdir].meta)
+		if err != nil {
+			if rollbackErr := restoreStates(ctx, r, pushed, func(workdir string) string { return states[workdir].backup }); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+			return backups, err
+		}
+
+		pushed = append(pushed, workdir)
+	}
+
+	return backups, nil
+}
+
+// pushState pushes the given local state to the given working directory,
+// after checking that the remote state is still the version it was copied
+// from.
+func pushState(ctx context.Context, r stateRunner, workdir, path string, want stateMeta) error {
+	raw, err := r.pull(ctx, workdir)
+	if err != nil {
+		return fmt.Errorf("failed to pull state of %q: %w", workdir, err)
+	}
+
+	got, err := parseStateMeta(raw)
+	if err != nil {
+		return fmt.Errorf("failed to read state of %q: %w", workdir, err)
+	}
+
+	if got.Lineage != want.Lineage {
+		return fmt.Errorf("state of %q changed lineage from %q to %q since it was backed up", workdir, want.Lineage, got.Lineage)
+	}
+	if got.Serial != want.Serial {
+		return fmt.Errorf("state of %q changed serial from %d to %d since it was backed up", workdir, want.Serial, got.Serial)
+	}
+
+	if err := r.push(ctx, workdir, path, false); err != nil {
+		return fmt.Errorf("failed to push state of %q: %w", workdir, err)
+	}
+
+	return nil
+}
+
+// restoreStates pushes the backup of each given working directory. The push
+// is forced, since the backup's serial is lower than the state's.
+func restoreStates(ctx context.Context, r stateRunner, workdirs []string, backupOf func(string) string) error {
+	var errs []error
+	for _, workdir := range workdirs {
+		if err := r.push(ctx, workdir, backupOf(workdir), true); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore state of %q from %q: %w", workdir, backupOf(workdir), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// tfexecRunner runs Terraform commands with tfexec.
+type tfexecRunner struct {
+	terraformBin string
+}
+
+func (r tfexecRunner) pull(ctx context.Context, workdir string) ([]byte, error) {
+	tf, err := tfexec.NewTerraform(workdir, r.terraformBin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Terraform executor: %w", err)
+	}
+
+	raw, err := tf.StatePull(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(raw), nil
+}
+
+func (r tfexecRunner) push(ctx context.Context, workdir, path string, force bool) error {
+	tf, err := tfexec.NewTerraform(workdir, r.terraformBin)
+	if err != nil {
+		return fmt.Errorf("failed to create Terraform executor: %w", err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path of %q: %w", path, err)
+	}
+
+	return tf.StatePush(ctx, absPath, tfexec.Force(force))
+}
+
+func (r tfexecRunner) mv(ctx context.Context, from, to, state, stateOut string) error {
+	absState, err := filepath.Abs(state)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path of %q: %w", state, err)
+	}
+	absStateOut, err := filepath.Abs(stateOut)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path of %q: %w", stateOut, err)
+	}
+
+	tf, err := tfexec.NewTerraform(filepath.Dir(absState), r.terraformBin)
+	if err != nil {
+		return fmt.Errorf("failed to create Terraform executor: %w", err)
+	}
+
+	// The backups ApplyMoves makes replace those Terraform would make.
+	return tf.StateMv(ctx, from, to,
+		tfexec.State(absState),
+		tfexec.StateOut(absStateOut),
+		tfexec.DisableBackup(),
+	)
+}
@@ -0,0 +1,203 @@
+package terraform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeRunner keeps states in memory instead of running Terraform.
+type fakeRunner struct {
+	states   map[string]string
+	failPush string
+	// Changes the state of a working directory once it has been pulled.
+	concurrentChange string
+
+	pulls  map[string]int
+	pushes []string
+	moves  []string
+}
+
+func (r *fakeRunner) pull(_ context.Context, workdir string) ([]byte, error) {
+	if r.pulls == nil {
+		r.pulls = make(map[string]int)
+	}
+	r.pulls[workdir]++
+
+	if workdir == r.concurrentChange && r.pulls[workdir] > 1 {
+		return []byte(`{"lineage": "l", "serial": 99}`), nil
+	}
+
+	return []byte(r.states[workdir]), nil
+}
+
+func (r *fakeRunner) push(_ context.Context, workdir, path string, force bool) error {
+	r.pushes = append(r.pushes, fmt.Sprintf("%s %s force=%v", workdir, filepath.Base(path), force))
+
+	if workdir == r.failPush && !force {
+		return errors.New("push failed")
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	r.states[workdir] = string(raw)
+
+	return nil
+}
+
+func (r *fakeRunner) mv(_ context.Context, from, to, state, stateOut string) error {
+	r.moves = append(r.moves, fmt.Sprintf("%s %s %s %s", from, to, filepath.Base(filepath.Dir(state)), filepath.Base(filepath.Dir(stateOut))))
+	return nil
+}
+
+func TestApplyMoves(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	backupName := ".tfautomv-backup-20240102T030405.tfstate"
+
+	setup := func(t *testing.T) (a, b string, r *fakeRunner) {
+		root := t.TempDir()
+		a, b = filepath.Join(root, "a"), filepath.Join(root, "b")
+		for _, dir := range []string{a, b} {
+			if err := os.Mkdir(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		r = &fakeRunner{
+			states: map[string]string{
+				a: `{"lineage": "la", "serial": 1}`,
+				b: `{"lineage": "lb", "serial": 7}`,
+			},
+		}
+
+		return a, b, r
+	}
+
+	t.Run("success", func(t *testing.T) {
+		a, b, r := setup(t)
+
+		moves := []Move{
+			{FromWorkdir: a, ToWorkdir: a, FromAddress: "aws_instance.x", ToAddress: "aws_instance.y"},
+			{FromWorkdir: a, ToWorkdir: b, FromAddress: "aws_instance.z", ToAddress: "aws_instance.z"},
+		}
+
+		backups, err := applyMoves(context.Background(), r, moves, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		wantBackups := []string{filepath.Join(a, backupName), filepath.Join(b, backupName)}
+		if diff := cmp.Diff(wantBackups, backups); diff != "" {
+			t.Errorf("backups mismatch (-want +got):\n%s", diff)
+		}
+
+		wantMoves := []string{
+			"aws_instance.x aws_instance.y a a",
+			"aws_instance.z aws_instance.z a b",
+		}
+		if diff := cmp.Diff(wantMoves, r.moves); diff != "" {
+			t.Errorf("moves mismatch (-want +got):\n%s", diff)
+		}
+
+		wantPushes := []string{
+			a + " .tfautomv.tfstate force=false",
+			b + " .tfautomv.tfstate force=false",
+		}
+		if diff := cmp.Diff(wantPushes, r.pushes); diff != "" {
+			t.Errorf("pushes mismatch (-want +got):\n%s", diff)
+		}
+
+		for _, dir := range []string{a, b} {
+			if _, err := os.Stat(filepath.Join(dir, localCopyFileName)); !os.IsNotExist(err) {
+				t.Errorf("local copy of state in %q was not removed", dir)
+			}
+
+			info, err := os.Stat(filepath.Join(dir, backupName))
+			if err != nil {
+				t.Fatalf("backup in %q: %v", dir, err)
+			}
+			if info.Mode().Perm() != 0600 {
+				t.Errorf("backup in %q has mode %v, want 0600", dir, info.Mode().Perm())
+			}
+		}
+	})
+
+	t.Run("destination without state", func(t *testing.T) {
+		a, b, r := setup(t)
+		r.states[b] = ""
+
+		moves := []Move{
+			{FromWorkdir: a, ToWorkdir: b, FromAddress: "aws_instance.z", ToAddress: "aws_instance.z"},
+		}
+
+		_, err := applyMoves(context.Background(), r, moves, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		wantPushes := []string{
+			a + " .tfautomv.tfstate force=false",
+			b + " .tfautomv.tfstate force=false",
+		}
+		if diff := cmp.Diff(wantPushes, r.pushes); diff != "" {
+			t.Errorf("pushes mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("rollback after failed push", func(t *testing.T) {
+		a, b, r := setup(t)
+		r.failPush = b
+
+		moves := []Move{
+			{FromWorkdir: a, ToWorkdir: b, FromAddress: "aws_instance.z", ToAddress: "aws_instance.z"},
+		}
+
+		_, err := applyMoves(context.Background(), r, moves, now)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+
+		wantPushes := []string{
+			a + " .tfautomv.tfstate force=false",
+			b + " .tfautomv.tfstate force=false",
+			a + " " + backupName + " force=true",
+		}
+		if diff := cmp.Diff(wantPushes, r.pushes); diff != "" {
+			t.Errorf("pushes mismatch (-want +got):\n%s", diff)
+		}
+
+		if r.states[a] != `{"lineage": "la", "serial": 1}` {
+			t.Errorf("state of %q was not restored, got %s", a, r.states[a])
+		}
+	})
+
+	t.Run("state changed since backup", func(t *testing.T) {
+		a, b, r := setup(t)
+		r.concurrentChange = b
+
+		moves := []Move{
+			{FromWorkdir: a, ToWorkdir: b, FromAddress: "aws_instance.z", ToAddress: "aws_instance.z"},
+		}
+
+		_, err := applyMoves(context.Background(), r, moves, now)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+
+		wantPushes := []string{
+			a + " .tfautomv.tfstate force=false",
+			a + " " + backupName + " force=true",
+		}
+		if diff := cmp.Diff(wantPushes, r.pushes); diff != "" {
+			t.Errorf("pushes mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
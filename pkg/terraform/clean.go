@@ -0,0 +1,179 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// A CleanedFile is a configuration file without the moved blocks Terraform
+// already applied to the state.
+type CleanedFile struct {
+	// The path to the file.
+	Path string
+
+	// The file's content, before and after removing the moved blocks.
+	Before, After []byte
+
+	// The moves described by the removed blocks.
+	Removed []Move
+}
+
+// CleanMovedBlocks finds the moved blocks in the given working directory that
+// Terraform already applied to the state: those whose source address no longer
+// exists in the state, and whose destination address does. The addresses are
+// those of the resources in the state, as returned by StateAddresses.
+//
+// Only files with one of the given extensions are considered. Files in JSON
+// syntax are left alone, since tfautomv never writes them. CleanMovedBlocks
+// does not modify any file; it returns the new content of each file with
+// blocks to remove.
+func CleanMovedBlocks(workdir string, extensions []string, addresses []string) ([]CleanedFile, error) {
+	var cleaned []CleanedFile
+
+	err := walkConfigFiles(workdir, extensions, func(path string, src []byte) error {
+		if isJSON(path) {
+			return nil
+		}
+
+		blocks, err := parseMovedBlocks(src, path)
+		if err != nil {
+			return err
+		}
+
+		var stale []movedBlock
+		for _, b := range blocks {
+			if isApplied(b.move, addresses) {
+				stale = append(stale, b)
+			}
+		}
+
+		if len(stale) == 0 {
+			return nil
+		}
+
+		file := CleanedFile{
+			Path:   path,
+			Before: src,
+			After:  removeBlocks(src, stale),
+		}
+		for _, b := range stale {
+			m := b.move
+			m.FromWorkdir = workdir
+			m.ToWorkdir = workdir
+			file.Removed = append(file.Removed, m)
+		}
+
+		cleaned = append(cleaned, file)
+		return nil
+	})
+
+	return cleaned, err
+}
+
+// isApplied reports whether Terraform already applied the given move to a
+// state containing the given addresses.
+func isApplied(m Move, addresses []string) bool {
+	return !containsAddress(addresses, m.FromAddress) && containsAddress(addresses, m.ToAddress)
+}
+
+// containsAddress reports whether one of the given resource addresses is the
+// given address, or is within it. For example, `module.a[0].aws_instance.b`
+// is within `module.a`, `module.a[0]`, and `module.a[0].aws_instance.b`.
+func containsAddress(addresses []string, address string) bool {
+	for _, a := range addresses {
+		if a == address || strings.HasPrefix(a, address+".") || strings.HasPrefix(a, address+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// removeBlocks removes the given blocks from the source, along with the line
+// break that follows each block and a blank line that would be left behind.
+func removeBlocks(src []byte, blocks []movedBlock) []byte {
+	var result []byte
+	last := 0
+
+	for _, b := range blocks {
+		start, end := b.rng.Start.Byte, b.rng.End.Byte
+		if start < last {
+			continue
+		}
+
+		// Remove the rest of the block's line.
+		for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+			end++
+		}
+		if end < len(src) && src[end] == '\n' {
+			end++
+		}
+
+		// Remove one of the blank lines around the block, if there are two.
+		blankBefore := start >= 2 && src[start-1] == '\n' && src[start-2] == '\n'
+		blankAfter := end < len(src) && src[end] == '\n'
+		if blankAfter && (blankBefore || start == 0) {
+			end++
+		} else if blankBefore && end == len(src) && start > last {
+			start--
+		}
+
+		result = append(result, src[last:start]...)
+		last = end
+	}
+
+	result = append(result, src[last:]...)
+
+	return result
+}
+
+// ManagesState reports whether the module in the given working directory
+// looks like a root module whose state it manages: its configuration declares
+// a backend or a cloud block, or a local state file is present. Modules that
+// do neither are usually published for others to call, and their moved blocks
+// must stay until every caller has applied them.
+func ManagesState(workdir string, extensions []string) (bool, error) {
+	for _, name := range []string{"terraform.tfstate", filepath.Join(".terraform", "terraform.tfstate")} {
+		if _, err := os.Stat(filepath.Join(workdir, name)); err == nil {
+			return true, nil
+		}
+	}
+
+	managesState := false
+
+	err := walkConfigFiles(workdir, extensions, func(path string, src []byte) error {
+		body, err := parseConfig(src, path)
+		if err != nil {
+			return err
+		}
+
+		content, _, diags := body.PartialContent(&hcl.BodySchema{
+			Blocks: []hcl.BlockHeaderSchema{{Type: "terraform"}},
+		})
+		if diags.HasErrors() {
+			return fmt.Errorf("failed to parse %q: %w", path, diags)
+		}
+
+		for _, block := range content.Blocks {
+			inner, _, diags := block.Body.PartialContent(&hcl.BodySchema{
+				Blocks: []hcl.BlockHeaderSchema{
+					{Type: "backend", LabelNames: []string{"type"}},
+					{Type: "cloud"},
+				},
+			})
+			if diags.HasErrors() {
+				return fmt.Errorf("failed to parse %q: %w", path, diags)
+			}
+			if len(inner.Blocks) > 0 {
+				managesState = true
+			}
+		}
+
+		return nil
+	})
+
+	return managesState, err
+}
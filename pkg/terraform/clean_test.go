@@ -0,0 +1,177 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCleanMovedBlocks(t *testing.T) {
+	workdir := t.TempDir()
+
+	files := map[string]string{
+		"moves.tf": `moved {
+  from = aws_instance.applied
+  to   = aws_instance.a
+}
+
+moved {
+  from = aws_instance.pending
+  to   = aws_instance.b
+}
+
+moved {
+  from = module.old
+  to   = module.new
+}
+`,
+		"main.tf": `resource "aws_instance" "a" {}
+
+moved {
+  from = aws_instance.gone
+  to   = aws_instance.also_gone
+}
+`,
+		"only.tf": `moved {
+  from = aws_instance.x[0]
+  to   = aws_instance.x["key"]
+}
+`,
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(workdir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	addresses := []string{
+		"aws_instance.a",
+		"aws_instance.pending",
+		"aws_instance.b",
+		"module.new.aws_instance.c",
+		`aws_instance.x["key"]`,
+	}
+
+	got, err := CleanMovedBlocks(workdir, []string{".tf"}, addresses)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	move := func(from, to string) Move {
+		return Move{FromWorkdir: workdir, ToWorkdir: workdir, FromAddress: from, ToAddress: to}
+	}
+
+	want := []CleanedFile{
+		{
+			Path:   filepath.Join(workdir, "moves.tf"),
+			Before: []byte(files["moves.tf"]),
+			After: []byte(`moved {
+  from = aws_instance.pending
+  to   = aws_instance.b
+}
+`),
+			Removed: []Move{
+				move("aws_instance.applied", "aws_instance.a"),
+				move("module.old", "module.new"),
+			},
+		},
+		{
+			Path:    filepath.Join(workdir, "only.tf"),
+			Before:  []byte(files["only.tf"]),
+			Removed: []Move{move("aws_instance.x[0]", `aws_instance.x["key"]`)},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestContainsAddress(t *testing.T) {
+	addresses := []string{
+		`module.a[0].aws_instance.b["x"]`,
+		"aws_instance.foo",
+	}
+
+	tests := map[string]bool{
+		"module.a":                        true,
+		"module.a[0]":                     true,
+		"module.a[0].aws_instance.b":      true,
+		`module.a[0].aws_instance.b["x"]`: true,
+		"module.a[1]":                     false,
+		"module.ab":                       false,
+		"aws_instance.foo":                true,
+		"aws_instance.fo":                 false,
+		"aws_instance.foo[0]":             false,
+	}
+
+	for address, want := range tests {
+		if got := containsAddress(addresses, address); got != want {
+			t.Errorf("containsAddress(%q) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestManagesState(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string
+		want  bool
+	}{
+		"published module": {
+			files: map[string]string{
+				"main.tf": `terraform {
+  required_version = ">= 1.5"
+}
+`,
+			},
+			want: false,
+		},
+		"backend": {
+			files: map[string]string{
+				"backend.tf": `terraform {
+  backend "s3" {}
+}
+`,
+			},
+			want: true,
+		},
+		"cloud": {
+			files: map[string]string{
+				"main.tf": `terraform {
+  cloud {}
+}
+`,
+			},
+			want: true,
+		},
+		"local state": {
+			files: map[string]string{
+				"main.tf":           `resource "null_resource" "a" {}`,
+				"terraform.tfstate": `{}`,
+			},
+			want: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			workdir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(workdir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := ManagesState(workdir, []string{".tf"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ManagesState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
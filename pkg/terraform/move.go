@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/busser/tfautomv/pkg/engine"
 )
 
 // A Move represents a an object in Terraform's state that should be moved to
@@ -25,12 +27,48 @@ type Move struct {
 	FromAddress string
 	// The resource's address after the move.
 	ToAddress string
+
+	// The ID Terraform can import the resource with. Only needed to move the
+	// resource across working directories with removed and import blocks.
+	ImportID string
+	// The resource's identity, which Terraform v1.12+ can import the resource
+	// with instead of an ID. Takes precedence over ImportID when set.
+	ImportIdentity map[string]any
 }
 
 func (m Move) block() string {
 	return fmt.Sprintf("moved {\n  from = %s\n  to   = %s\n}", m.FromAddress, m.ToAddress)
 }
 
+func (m Move) removedBlock() string {
+	return fmt.Sprintf("removed {\n  from = %s\n\n  lifecycle {\n    destroy = false\n  }\n}", engine.ConfigAddress(m.FromAddress))
+}
+
+func (m Move) importBlock() string {
+	if len(m.ImportIdentity) == 0 {
+		return fmt.Sprintf("import {\n  to = %s\n  id = %s\n}", m.ToAddress, hclValue(m.ImportID))
+	}
+
+	keys := make([]string, 0, len(m.ImportIdentity))
+	width := 0
+	for k := range m.ImportIdentity {
+		keys = append(keys, k)
+		width = max(width, len(k))
+	}
+	sort.Strings(keys)
+
+	var attributes []string
+	for _, k := range keys {
+		attributes = append(attributes, fmt.Sprintf("    %-*s = %s", width, k, hclValue(m.ImportIdentity[k])))
+	}
+
+	return fmt.Sprintf("import {\n  to = %s\n  identity = {\n%s\n  }\n}", m.ToAddress, strings.Join(attributes, "\n"))
+}
+
+func (m Move) isImportable() bool {
+	return m.ImportID != "" || len(m.ImportIdentity) > 0
+}
+
 func (m Move) isWithinSameWorkdir() bool {
 	return m.FromWorkdir == m.ToWorkdir
 }
@@ -41,6 +79,12 @@ func (m Move) isWithinSameWorkdir() bool {
 // Currently, moved blocks cannot be used to move resources between different
 // working directories. If the given moves contain such a move, WriteMovedBlocks
 // returns an error.
+//
+// Moved blocks cannot express moves that form a chain, like `a` to `b` and
+// `b` to `c`: Terraform follows the chain and moves `a` to `c`, and does not
+// move `b` if `a` already moved there. Terraform also rejects cycles, like `a`
+// and `b` swapping addresses. If the given moves contain a chain or a cycle,
+// WriteMovedBlocks returns an error. See SplitChains.
 func WriteMovedBlocks(w io.Writer, moves []Move) error {
 	if len(moves) == 0 {
 		return nil
@@ -56,6 +100,115 @@ func WriteMovedBlocks(w io.Writer, moves []Move) error {
 		blocks = append(blocks, move.block())
 	}
 
+	if _, chained := SplitChains(moves); len(chained) > 0 {
+		return fmt.Errorf("cannot write blocks for moves that form a chain or a cycle, such as %s to %s", chained[0].FromAddress, chained[0].ToAddress)
+	}
+
+	_, err := w.Write([]byte(strings.Join(blocks, "\n") + "\n"))
+
+	return err
+}
+
+// WriteRemovedBlocks encodes the given moves as a series of Terraform removed
+// blocks, in HCL, and writes them to the given writer. The blocks remove the
+// resources from the state of their original working directory without
+// destroying them. Together with the blocks written by WriteImportBlocks, they
+// move resources across working directories without editing state directly.
+//
+// Removed blocks apply to all instances of a resource, so a single block is
+// written for moves of several instances of the same resource. Moves of some
+// instances only must be left out, or the other instances would be forgotten
+// too. See SplitPartialRemovals.
+//
+// Removed blocks require Terraform v1.7 or later.
+func WriteRemovedBlocks(w io.Writer, moves []Move) error {
+	if len(moves) == 0 {
+		return nil
+	}
+
+	var blocks []string
+	seen := make(map[string]bool)
+
+	for _, move := range moves {
+		from := engine.ConfigAddress(move.FromAddress)
+		if seen[from] {
+			continue
+		}
+		seen[from] = true
+
+		blocks = append(blocks, move.removedBlock())
+	}
+
+	_, err := w.Write([]byte(strings.Join(blocks, "\n") + "\n"))
+
+	return err
+}
+
+// SplitPartialRemovals separates the moves that WriteRemovedBlocks can write
+// from those of resources with instances that stay in their original working
+// directory. The given instances map each working directory to the addresses
+// of all resource instances in its plan, whatever Terraform plans for them.
+//
+// A removed block forgets every instance of a resource, and Terraform rejects
+// it while the resource is still declared. So a resource can only be removed
+// when all of its instances are moved.
+func SplitPartialRemovals(moves []Move, instances map[string][]string) (removable, partial []Move) {
+	type resource struct {
+		workdir, address string
+	}
+
+	moved := make(map[resource]map[string]bool)
+	for _, m := range moves {
+		r := resource{m.FromWorkdir, engine.ConfigAddress(m.FromAddress)}
+		if moved[r] == nil {
+			moved[r] = make(map[string]bool)
+		}
+		moved[r][m.FromAddress] = true
+	}
+
+	stays := make(map[resource]bool)
+	for workdir, addresses := range instances {
+		for _, address := range addresses {
+			r := resource{workdir, engine.ConfigAddress(address)}
+			if movedInstances, ok := moved[r]; ok && !movedInstances[address] {
+				stays[r] = true
+			}
+		}
+	}
+
+	for _, m := range moves {
+		if stays[resource{m.FromWorkdir, engine.ConfigAddress(m.FromAddress)}] {
+			partial = append(partial, m)
+		} else {
+			removable = append(removable, m)
+		}
+	}
+
+	return removable, partial
+}
+
+// WriteImportBlocks encodes the given moves as a series of Terraform import
+// blocks, in HCL, and writes them to the given writer. The blocks import the
+// resources into the state of their new working directory. See
+// WriteRemovedBlocks.
+//
+// Each move must have an import ID or identity. If one of the given moves has
+// neither, WriteImportBlocks returns an error.
+func WriteImportBlocks(w io.Writer, moves []Move) error {
+	if len(moves) == 0 {
+		return nil
+	}
+
+	var blocks []string
+
+	for _, move := range moves {
+		if !move.isImportable() {
+			return fmt.Errorf("no import ID for %s", move.ToAddress)
+		}
+
+		blocks = append(blocks, move.importBlock())
+	}
+
 	_, err := w.Write([]byte(strings.Join(blocks, "\n") + "\n"))
 
 	return err
@@ -90,9 +243,23 @@ func WriteMoveCommands(w io.Writer, moves []Move, opts ...Option) error {
 func writeMoveCommands(w io.Writer, moves []Move, terraformBin string) error {
 	var commands []string
 
-	// Start with moves within the same module.
+	// Moves must run in an order where each resource's destination is free,
+	// so that moves forming a chain or a cycle do not overwrite each other.
 
+	var sameWorkdir, differentWorkdir []Move
 	for _, m := range moves {
+		if m.isWithinSameWorkdir() {
+			sameWorkdir = append(sameWorkdir, m)
+		} else {
+			differentWorkdir = append(differentWorkdir, m)
+		}
+	}
+	sameWorkdir = orderMoves(sameWorkdir)
+	differentWorkdir = orderMoves(differentWorkdir)
+
+	// Start with moves within the same module.
+
+	for _, m := range sameWorkdir {
 		if m.FromWorkdir == m.ToWorkdir {
 			var chdirFlag string
 			if m.FromWorkdir != "." {
@@ -114,16 +281,12 @@ func writeMoveCommands(w io.Writer, moves []Move, terraformBin string) error {
 	// cross-directory moves.
 
 	var workdirs []string
-	for _, m := range moves {
-		if m.FromWorkdir != m.ToWorkdir {
-			workdirs = append(workdirs, m.FromWorkdir, m.ToWorkdir)
-		}
+	for _, m := range differentWorkdir {
+		workdirs = append(workdirs, m.FromWorkdir, m.ToWorkdir)
 	}
 	workdirs = unique(workdirs)
 	sort.Strings(workdirs)
 
-	const localCopyFileName = ".tfautomv.tfstate"
-
 	for _, workdir := range workdirs {
 		commands = append(commands,
 			fmt.Sprintf("%s -chdir=%q state pull > %q",
@@ -136,12 +299,7 @@ func writeMoveCommands(w io.Writer, moves []Move, terraformBin string) error {
 
 	// Next, perform all the moves.
 
-	for _, move := range moves {
-		if move.FromWorkdir == move.ToWorkdir {
-			// Already handled above.
-			continue
-		}
-
+	for _, move := range differentWorkdir {
 		commands = append(commands,
 			fmt.Sprintf("%s state mv -state=%q -state-out=%q %q %q",
 				terraformBin,
@@ -172,6 +330,112 @@ func writeMoveCommands(w io.Writer, moves []Move, terraformBin string) error {
 	return err
 }
 
+// SplitChains separates the moves that form a chain, like `a` to `b` and `b`
+// to `c`, from the other moves. A cycle, like `a` and `b` swapping addresses,
+// is a chain that comes back to where it started. Such moves must happen in
+// order, with state mv commands, so that each destination is free when a
+// resource moves there.
+func SplitChains(moves []Move) (independent, chained []Move) {
+	next := blockers(moves)
+
+	isBlocker := make(map[int]bool)
+	for _, j := range next {
+		isBlocker[j] = true
+	}
+
+	for i, m := range moves {
+		if _, isBlocked := next[i]; isBlocked || isBlocker[i] {
+			chained = append(chained, m)
+		} else {
+			independent = append(independent, m)
+		}
+	}
+
+	return independent, chained
+}
+
+// blockers maps each move to the move that must happen before it, because it
+// frees the first move's destination.
+func blockers(moves []Move) map[int]int {
+	type location struct {
+		workdir, address string
+	}
+
+	bySource := make(map[location]int)
+	for i, m := range moves {
+		bySource[location{m.FromWorkdir, m.FromAddress}] = i
+	}
+
+	next := make(map[int]int)
+	for i, m := range moves {
+		if j, ok := bySource[location{m.ToWorkdir, m.ToAddress}]; ok && j != i {
+			next[i] = j
+		}
+	}
+
+	return next
+}
+
+// orderMoves orders the given moves so that each move's destination is free
+// when the move happens. Moves that form a cycle go through a temporary
+// address: one resource of the cycle is moved out of the way first, and moved
+// to its destination last.
+func orderMoves(moves []Move) []Move {
+	moves = append([]Move(nil), moves...)
+	next := blockers(moves)
+
+	const (
+		pending = iota
+		visiting
+		done
+	)
+	state := make([]int, len(moves))
+
+	var ordered []Move
+	var visit func(i int)
+	visit = func(i int) {
+		state[i] = visiting
+
+		if j, ok := next[i]; ok {
+			switch state[j] {
+			case pending:
+				visit(j)
+			case visiting:
+				// The moves form a cycle: move j's resource out of the way,
+				// so that move i can happen. Move j happens last, from the
+				// temporary address.
+				tmp := temporaryAddress(moves[j].FromAddress)
+				ordered = append(ordered, Move{
+					FromWorkdir: moves[j].FromWorkdir,
+					ToWorkdir:   moves[j].FromWorkdir,
+					FromAddress: moves[j].FromAddress,
+					ToAddress:   tmp,
+				})
+				moves[j].FromAddress = tmp
+			}
+		}
+
+		ordered = append(ordered, moves[i])
+		state[i] = done
+	}
+
+	for i := range moves {
+		if state[i] == pending {
+			visit(i)
+		}
+	}
+
+	return ordered
+}
+
+// temporaryAddress returns an address, close to the given one, that a resource
+// can be moved to while another resource takes its place. For example,
+// `aws_instance.foo["a"]` becomes `aws_instance.foo_tfautomv_tmp["a"]`.
+func temporaryAddress(address string) string {
+	resource, _ := engine.InstanceKey(address)
+	return resource + "_tfautomv_tmp" + address[len(resource):]
+}
+
 func unique(s []string) []string {
 	seen := make(map[string]struct{})
 	var unique []string
@@ -183,3 +447,19 @@ func unique(s []string) []string {
 	}
 	return unique
 }
+
+// hclValue encodes a primitive value as an HCL literal.
+func hclValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		// Template sequences must be escaped, or Terraform would interpret
+		// them.
+		v = strings.ReplaceAll(v, "${", "$${")
+		v = strings.ReplaceAll(v, "%{", "%%{")
+		return fmt.Sprintf("%q", v)
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprint(v)
+	}
+}
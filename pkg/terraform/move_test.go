@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/busser/tfautomv/pkg/golden"
 )
 
@@ -53,6 +55,42 @@ func TestWriteMovedBlocks(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "chain within same workdir",
+			moves: []Move{
+				{
+					FromWorkdir: "/path/to/workdir",
+					ToWorkdir:   "/path/to/workdir",
+					FromAddress: "aws_instance.a",
+					ToAddress:   "aws_instance.b",
+				},
+				{
+					FromWorkdir: "/path/to/workdir",
+					ToWorkdir:   "/path/to/workdir",
+					FromAddress: "aws_instance.b",
+					ToAddress:   "aws_instance.c",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "swap within same workdir",
+			moves: []Move{
+				{
+					FromWorkdir: "/path/to/workdir",
+					ToWorkdir:   "/path/to/workdir",
+					FromAddress: "aws_instance.a",
+					ToAddress:   "aws_instance.b",
+				},
+				{
+					FromWorkdir: "/path/to/workdir",
+					ToWorkdir:   "/path/to/workdir",
+					FromAddress: "aws_instance.b",
+					ToAddress:   "aws_instance.a",
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -147,6 +185,80 @@ func TestWriteMoveCommands(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "chain within same workdir",
+			moves: []Move{
+				{
+					FromWorkdir: ".",
+					ToWorkdir:   ".",
+					FromAddress: "aws_instance.a",
+					ToAddress:   "aws_instance.b",
+				},
+				{
+					FromWorkdir: ".",
+					ToWorkdir:   ".",
+					FromAddress: "aws_instance.b",
+					ToAddress:   "aws_instance.c",
+				},
+			},
+		},
+		{
+			name: "swap within same workdir",
+			moves: []Move{
+				{
+					FromWorkdir: ".",
+					ToWorkdir:   ".",
+					FromAddress: `aws_instance.a["x"]`,
+					ToAddress:   `aws_instance.a["y"]`,
+				},
+				{
+					FromWorkdir: ".",
+					ToWorkdir:   ".",
+					FromAddress: `aws_instance.a["y"]`,
+					ToAddress:   `aws_instance.a["x"]`,
+				},
+			},
+		},
+		{
+			name: "cycle of three",
+			moves: []Move{
+				{
+					FromWorkdir: ".",
+					ToWorkdir:   ".",
+					FromAddress: "aws_instance.a",
+					ToAddress:   "aws_instance.b",
+				},
+				{
+					FromWorkdir: ".",
+					ToWorkdir:   ".",
+					FromAddress: "aws_instance.b",
+					ToAddress:   "aws_instance.c",
+				},
+				{
+					FromWorkdir: ".",
+					ToWorkdir:   ".",
+					FromAddress: "aws_instance.c",
+					ToAddress:   "aws_instance.a",
+				},
+			},
+		},
+		{
+			name: "swap between different workdirs",
+			moves: []Move{
+				{
+					FromWorkdir: "/path/to/workdir1",
+					ToWorkdir:   "/path/to/workdir2",
+					FromAddress: "aws_instance.a",
+					ToAddress:   "aws_instance.a",
+				},
+				{
+					FromWorkdir: "/path/to/workdir2",
+					ToWorkdir:   "/path/to/workdir1",
+					FromAddress: "aws_instance.a",
+					ToAddress:   "aws_instance.a",
+				},
+			},
+		},
 		{
 			name: "non-default terraform binary",
 			moves: []Move{
@@ -182,3 +294,226 @@ func TestWriteMoveCommands(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteRemovedBlocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []Move
+	}{
+		{
+			name: "single move",
+			moves: []Move{
+				{
+					FromWorkdir: "/path/to/workdir1",
+					ToWorkdir:   "/path/to/workdir2",
+					FromAddress: "aws_instance.foo",
+					ToAddress:   "aws_instance.bar",
+				},
+			},
+		},
+		{
+			name: "multiple instances of same resource",
+			moves: []Move{
+				{
+					FromWorkdir: "/path/to/workdir1",
+					ToWorkdir:   "/path/to/workdir2",
+					FromAddress: `module.a[0].aws_instance.foo["x"]`,
+					ToAddress:   "aws_instance.bar",
+				},
+				{
+					FromWorkdir: "/path/to/workdir1",
+					ToWorkdir:   "/path/to/workdir2",
+					FromAddress: `module.a[1].aws_instance.foo["y"]`,
+					ToAddress:   "aws_instance.baz",
+				},
+				{
+					FromWorkdir: "/path/to/workdir1",
+					ToWorkdir:   "/path/to/workdir2",
+					FromAddress: "aws_instance.qux[0]",
+					ToAddress:   "aws_instance.qux",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+
+			err := WriteRemovedBlocks(buf, tt.moves)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			golden.Equal(t, buf.String())
+		})
+	}
+}
+
+func TestSplitPartialRemovals(t *testing.T) {
+	move := func(from string) Move {
+		return Move{FromWorkdir: "old", ToWorkdir: "new", FromAddress: from, ToAddress: from}
+	}
+
+	moves := []Move{
+		move(`aws_instance.all["a"]`),
+		move(`aws_instance.all["b"]`),
+		move(`aws_instance.some["a"]`),
+		move(`aws_instance.declared[0]`),
+		move(`aws_instance.single`),
+	}
+
+	instances := map[string][]string{
+		"old": {
+			`aws_instance.all["a"]`,
+			`aws_instance.all["b"]`,
+			`aws_instance.some["a"]`,
+			`aws_instance.some["b"]`,
+			`aws_instance.declared[0]`,
+			`aws_instance.declared[1]`,
+			`aws_instance.single`,
+		},
+		"new": {
+			`aws_instance.single`,
+		},
+	}
+
+	removable, partial := SplitPartialRemovals(moves, instances)
+
+	wantRemovable := []Move{move(`aws_instance.all["a"]`), move(`aws_instance.all["b"]`), move(`aws_instance.single`)}
+	wantPartial := []Move{move(`aws_instance.some["a"]`), move(`aws_instance.declared[0]`)}
+
+	if diff := cmp.Diff(wantRemovable, removable); diff != "" {
+		t.Errorf("removable mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantPartial, partial); diff != "" {
+		t.Errorf("partial mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteImportBlocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		moves   []Move
+		wantErr bool
+	}{
+		{
+			name: "import id",
+			moves: []Move{
+				{
+					FromWorkdir: "/path/to/workdir1",
+					ToWorkdir:   "/path/to/workdir2",
+					FromAddress: "aws_instance.foo",
+					ToAddress:   `aws_instance.bar["a"]`,
+					ImportID:    "i-1234567890abcdef0",
+				},
+				{
+					FromWorkdir: "/path/to/workdir1",
+					ToWorkdir:   "/path/to/workdir2",
+					FromAddress: "random_pet.foo",
+					ToAddress:   "random_pet.bar",
+					ImportID:    "weird-${id}",
+				},
+			},
+		},
+		{
+			name: "import identity",
+			moves: []Move{
+				{
+					FromWorkdir: "/path/to/workdir1",
+					ToWorkdir:   "/path/to/workdir2",
+					FromAddress: "aws_iam_role.foo",
+					ToAddress:   "aws_iam_role.bar",
+					ImportID:    "ignored",
+					ImportIdentity: map[string]any{
+						"name":       "my-role",
+						"account_id": "123456789012",
+					},
+				},
+			},
+		},
+		{
+			name: "missing import id",
+			moves: []Move{
+				{
+					FromWorkdir: "/path/to/workdir1",
+					ToWorkdir:   "/path/to/workdir2",
+					FromAddress: "aws_instance.foo",
+					ToAddress:   "aws_instance.bar",
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+
+			err := WriteImportBlocks(buf, tt.moves)
+
+			if err != nil && !tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && tt.wantErr {
+				t.Fatalf("expected error but got none")
+			}
+
+			golden.Equal(t, buf.String())
+		})
+	}
+}
+
+func TestSplitChains(t *testing.T) {
+	move := func(from, to string) Move {
+		return Move{FromWorkdir: ".", ToWorkdir: ".", FromAddress: from, ToAddress: to}
+	}
+
+	moves := []Move{
+		move("a", "b"),
+		move("b", "c"),
+		move("x", "y"),
+		move("y", "x"),
+		move("p", "q"),
+		move("q", "r"),
+		move("r", "p"),
+		move("s", "x"),
+		move("m", "n"),
+	}
+
+	independent, chained := SplitChains(moves)
+
+	wantIndependent := []Move{move("m", "n")}
+	wantChained := []Move{
+		move("a", "b"), move("b", "c"),
+		move("x", "y"), move("y", "x"),
+		move("p", "q"), move("q", "r"), move("r", "p"),
+		move("s", "x"),
+	}
+
+	if diff := cmp.Diff(wantIndependent, independent); diff != "" {
+		t.Errorf("independent mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantChained, chained); diff != "" {
+		t.Errorf("chained mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTemporaryAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"aws_instance.a", "aws_instance.a_tfautomv_tmp"},
+		{"aws_instance.a[0]", "aws_instance.a_tfautomv_tmp[0]"},
+		{`aws_instance.a["x.y[0]"]`, `aws_instance.a_tfautomv_tmp["x.y[0]"]`},
+		{`module.m[0].aws_instance.a`, `module.m[0].aws_instance.a_tfautomv_tmp`},
+		{`module.m["k"].aws_instance.a["x"]`, `module.m["k"].aws_instance.a_tfautomv_tmp["x"]`},
+	}
+
+	for _, tt := range tests {
+		if got := temporaryAddress(tt.address); got != tt.want {
+			t.Errorf("temporaryAddress(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
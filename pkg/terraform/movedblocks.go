@@ -0,0 +1,322 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ReadMovedBlocks parses the configuration files in the given working
+// directory, and returns the moves described by the moved blocks they contain.
+// Only files with one of the given extensions are parsed, for example ".tf"
+// and ".tf.json".
+func ReadMovedBlocks(workdir string, extensions []string) ([]Move, error) {
+	var moves []Move
+
+	err := walkConfigFiles(workdir, extensions, func(path string, src []byte) error {
+		blocks, err := parseMovedBlocks(src, path)
+		if err != nil {
+			return err
+		}
+
+		for _, b := range blocks {
+			m := b.move
+			m.FromWorkdir = workdir
+			m.ToWorkdir = workdir
+			moves = append(moves, m)
+		}
+
+		return nil
+	})
+
+	return moves, err
+}
+
+// ReadRemovedAndImportBlocks parses the configuration files in the given
+// working directory, and returns the addresses removed blocks remove and the
+// addresses import blocks import to. Only files with one of the given
+// extensions are parsed.
+func ReadRemovedAndImportBlocks(workdir string, extensions []string) (removed, imported []string, err error) {
+	err = walkConfigFiles(workdir, extensions, func(path string, src []byte) error {
+		body, err := parseConfig(src, path)
+		if err != nil {
+			return err
+		}
+
+		content, _, diags := body.PartialContent(&hcl.BodySchema{
+			Blocks: []hcl.BlockHeaderSchema{{Type: "removed"}, {Type: "import"}},
+		})
+		if diags.HasErrors() {
+			return fmt.Errorf("failed to parse %q: %w", path, diags)
+		}
+
+		for _, block := range content.Blocks {
+			name := "from"
+			if block.Type == "import" {
+				name = "to"
+			}
+
+			address, err := blockAddress(block, name)
+			if err != nil {
+				return fmt.Errorf("invalid %s block in %q: %w", block.Type, path, err)
+			}
+
+			if block.Type == "removed" {
+				removed = append(removed, address)
+			} else {
+				imported = append(imported, address)
+			}
+		}
+
+		return nil
+	})
+
+	return removed, imported, err
+}
+
+// walkConfigFiles calls fn with the path and content of each file in the
+// given working directory with one of the given extensions.
+func walkConfigFiles(workdir string, extensions []string, fn func(path string, src []byte) error) error {
+	entries, err := os.ReadDir(workdir)
+	if err != nil {
+		return fmt.Errorf("failed to read %q: %w", workdir, err)
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !hasExtension(e.Name(), extensions) {
+			continue
+		}
+
+		path := filepath.Join(workdir, e.Name())
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %q: %w", path, err)
+		}
+
+		if err := fn(path, src); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseConfig parses a configuration file, in JSON syntax if its name ends
+// with ".json" and in native syntax otherwise.
+func parseConfig(src []byte, filename string) (hcl.Body, error) {
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if isJSON(filename) {
+		file, diags = hcljson.Parse(src, filename)
+	} else {
+		file, diags = hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	}
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %q: %w", filename, diags)
+	}
+
+	return file.Body, nil
+}
+
+func isJSON(filename string) bool {
+	return strings.HasSuffix(filename, ".json")
+}
+
+// A movedBlock is a moved block found in a configuration file.
+type movedBlock struct {
+	move Move
+	// Where the block is in the file. Only set for files in native syntax.
+	rng hcl.Range
+}
+
+func parseMovedBlocks(src []byte, filename string) ([]movedBlock, error) {
+	body, err := parseConfig(src, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	content, _, diags := body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "moved"}},
+	})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %q: %w", filename, diags)
+	}
+
+	var blocks []movedBlock
+	for _, block := range content.Blocks {
+		from, err := blockAddress(block, "from")
+		if err != nil {
+			return nil, fmt.Errorf("invalid moved block in %q: %w", filename, err)
+		}
+		to, err := blockAddress(block, "to")
+		if err != nil {
+			return nil, fmt.Errorf("invalid moved block in %q: %w", filename, err)
+		}
+
+		b := movedBlock{
+			move: Move{
+				FromAddress: from,
+				ToAddress:   to,
+			},
+		}
+		if native, ok := block.Body.(*hclsyntax.Body); ok {
+			b.rng = hcl.RangeBetween(block.DefRange, native.SrcRange)
+		}
+
+		blocks = append(blocks, b)
+	}
+
+	return blocks, nil
+}
+
+func blockAddress(block *hcl.Block, name string) (string, error) {
+	content, _, diags := block.Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: name}},
+	})
+	if diags.HasErrors() {
+		return "", diags
+	}
+
+	attr, ok := content.Attributes[name]
+	if !ok {
+		return "", fmt.Errorf("missing %q attribute", name)
+	}
+
+	traversal, diags := hcl.AbsTraversalForExpr(attr.Expr)
+	if diags.HasErrors() {
+		return "", fmt.Errorf("%q is not an address: %w", name, diags)
+	}
+
+	return traversalAddress(traversal)
+}
+
+// traversalAddress formats a traversal the way Terraform formats addresses.
+func traversalAddress(traversal hcl.Traversal) (string, error) {
+	var b strings.Builder
+
+	for _, step := range traversal {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			b.WriteString(step.Name)
+		case hcl.TraverseAttr:
+			b.WriteString("." + step.Name)
+		case hcl.TraverseIndex:
+			switch step.Key.Type() {
+			case cty.Number:
+				b.WriteString("[" + step.Key.AsBigFloat().Text('f', -1) + "]")
+			case cty.String:
+				b.WriteString("[" + strconv.Quote(step.Key.AsString()) + "]")
+			default:
+				return "", fmt.Errorf("unsupported instance key %s", step.Key.GoString())
+			}
+		default:
+			return "", fmt.Errorf("unsupported traversal step %T", step)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func hasExtension(name string, extensions []string) bool {
+	for _, ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// A MovedBlockConflict describes a move that contradicts a moved block already
+// present in the configuration.
+type MovedBlockConflict struct {
+	// The move found in the configuration.
+	Existing Move
+	// The move that contradicts it.
+	New Move
+	// Whether the new move was left out because of the conflict. Moves that
+	// merely extend an existing moved block into a chain are kept.
+	Skipped bool
+}
+
+func (c MovedBlockConflict) String() string {
+	switch {
+	case c.Existing.FromAddress == c.New.FromAddress:
+		return fmt.Sprintf("%s is already moved to %s, not %s", c.New.FromAddress, c.Existing.ToAddress, c.New.ToAddress)
+	case c.Existing.ToAddress == c.New.ToAddress:
+		return fmt.Sprintf("%s is already the destination of %s, not %s", c.New.ToAddress, c.Existing.FromAddress, c.New.FromAddress)
+	default:
+		return fmt.Sprintf("moving %s to %s chains with the existing move from %s to %s", c.New.FromAddress, c.New.ToAddress, c.Existing.FromAddress, c.Existing.ToAddress)
+	}
+}
+
+// MergeMovedBlocks compares new moves to the moved blocks already present in
+// the configuration. It returns the moves that still need to be written, and
+// any conflicts between new moves and existing blocks.
+//
+// A new move identical to an existing block is left out. A new move with the
+// same source or destination as an existing block, but not both, is left out
+// and reported as a conflict. A new move that forms a chain with an existing
+// block, from the existing block's destination or to its source, is kept but
+// reported, since Terraform will follow the chain.
+func MergeMovedBlocks(existing, moves []Move) ([]Move, []MovedBlockConflict) {
+	type location struct {
+		workdir, address string
+	}
+
+	type pair struct {
+		from, to location
+	}
+	pairOf := func(m Move) pair {
+		return pair{location{m.FromWorkdir, m.FromAddress}, location{m.ToWorkdir, m.ToAddress}}
+	}
+
+	present := make(map[pair]bool)
+	bySource := make(map[location]Move)
+	byDestination := make(map[location]Move)
+	for _, e := range existing {
+		present[pairOf(e)] = true
+		bySource[location{e.FromWorkdir, e.FromAddress}] = e
+		byDestination[location{e.ToWorkdir, e.ToAddress}] = e
+	}
+
+	var merged []Move
+	var conflicts []MovedBlockConflict
+
+	for _, m := range moves {
+		if present[pairOf(m)] {
+			continue
+		}
+
+		if e, ok := bySource[location{m.FromWorkdir, m.FromAddress}]; ok {
+			conflicts = append(conflicts, MovedBlockConflict{Existing: e, New: m, Skipped: true})
+			continue
+		}
+		if e, ok := byDestination[location{m.ToWorkdir, m.ToAddress}]; ok {
+			conflicts = append(conflicts, MovedBlockConflict{Existing: e, New: m, Skipped: true})
+			continue
+		}
+
+		if e, ok := byDestination[location{m.FromWorkdir, m.FromAddress}]; ok {
+			conflicts = append(conflicts, MovedBlockConflict{Existing: e, New: m})
+		} else if e, ok := bySource[location{m.ToWorkdir, m.ToAddress}]; ok {
+			conflicts = append(conflicts, MovedBlockConflict{Existing: e, New: m})
+		}
+
+		present[pairOf(m)] = true
+		merged = append(merged, m)
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].New.FromAddress < conflicts[j].New.FromAddress
+	})
+
+	return merged, conflicts
+}
@@ -0,0 +1,201 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadMovedBlocks(t *testing.T) {
+	workdir := t.TempDir()
+
+	files := map[string]string{
+		"moves.tf": `
+moved {
+  from = aws_instance.foo
+  to   = aws_instance.bar
+}
+
+moved {
+  from = module.a[0]
+  to   = module.a["x"]
+}
+`,
+		"main.tf": `
+resource "aws_instance" "bar" {}
+
+moved {
+  from = aws_instance.baz["with.dot"]
+  to   = aws_instance.qux
+}
+`,
+		"override.tofu": `
+moved {
+  from = aws_instance.tofu
+  to   = aws_instance.only
+}
+`,
+		"generated.tf.json": `{
+  "moved": [
+    {"from": "aws_instance.json", "to": "aws_instance.from_json[\"a\"]"}
+  ]
+}`,
+		"notes.txt": `moved { from = a.b to = c.d }`,
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(workdir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	move := func(from, to string) Move {
+		return Move{FromWorkdir: workdir, ToWorkdir: workdir, FromAddress: from, ToAddress: to}
+	}
+
+	got, err := ReadMovedBlocks(workdir, []string{".tf", ".tf.json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Move{
+		move("aws_instance.json", `aws_instance.from_json["a"]`),
+		move(`aws_instance.baz["with.dot"]`, "aws_instance.qux"),
+		move("aws_instance.foo", "aws_instance.bar"),
+		move("module.a[0]", `module.a["x"]`),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = ReadMovedBlocks(workdir, []string{".tf", ".tofu"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 4 {
+		t.Errorf("got %d moves with .tofu files, want 4", len(got))
+	}
+}
+
+func TestReadRemovedAndImportBlocks(t *testing.T) {
+	workdir := t.TempDir()
+
+	files := map[string]string{
+		"moves.tf": `
+removed {
+  from = aws_instance.old
+
+  lifecycle {
+    destroy = false
+  }
+}
+
+import {
+  to = aws_instance.new["a"]
+  id = "i-123"
+}
+
+moved {
+  from = aws_instance.foo
+  to   = aws_instance.bar
+}
+`,
+		"imports.tf.json": `{
+  "import": [
+    {"to": "module.m.aws_instance.json", "id": "i-456"}
+  ]
+}`,
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(workdir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, imported, err := ReadRemovedAndImportBlocks(workdir, []string{".tf", ".tf.json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"aws_instance.old"}, removed); diff != "" {
+		t.Errorf("removed mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"module.m.aws_instance.json", `aws_instance.new["a"]`}, imported); diff != "" {
+		t.Errorf("imported mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadMovedBlocksInvalid(t *testing.T) {
+	tests := map[string]string{
+		"syntax error":   `moved {`,
+		"missing to":     "moved {\n  from = aws_instance.foo\n}\n",
+		"not an address": "moved {\n  from = \"aws_instance.foo\"\n  to   = aws_instance.bar\n}\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			workdir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(workdir, "moves.tf"), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := ReadMovedBlocks(workdir, []string{".tf"}); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestMergeMovedBlocks(t *testing.T) {
+	move := func(from, to string) Move {
+		return Move{FromWorkdir: ".", ToWorkdir: ".", FromAddress: from, ToAddress: to}
+	}
+
+	existing := []Move{
+		move("aws_instance.a", "aws_instance.b"),
+		move("aws_instance.c", "aws_instance.d"),
+		move("aws_instance.e", "aws_instance.f"),
+	}
+
+	moves := []Move{
+		move("aws_instance.a", "aws_instance.b"), // duplicate
+		move("aws_instance.c", "aws_instance.x"), // same source
+		move("aws_instance.y", "aws_instance.f"), // same destination
+		move("aws_instance.d", "aws_instance.z"), // chain
+		move("aws_instance.m", "aws_instance.n"), // new
+		move("aws_instance.m", "aws_instance.n"), // new, twice
+	}
+
+	gotMoves, gotConflicts := MergeMovedBlocks(existing, moves)
+
+	wantMoves := []Move{
+		move("aws_instance.d", "aws_instance.z"),
+		move("aws_instance.m", "aws_instance.n"),
+	}
+	wantConflicts := []MovedBlockConflict{
+		{Existing: existing[1], New: moves[1], Skipped: true},
+		{Existing: existing[1], New: moves[3]},
+		{Existing: existing[2], New: moves[2], Skipped: true},
+	}
+
+	if diff := cmp.Diff(wantMoves, gotMoves); diff != "" {
+		t.Errorf("moves mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantConflicts, gotConflicts); diff != "" {
+		t.Errorf("conflicts mismatch (-want +got):\n%s", diff)
+	}
+
+	wantStrings := []string{
+		"aws_instance.c is already moved to aws_instance.d, not aws_instance.x",
+		"moving aws_instance.d to aws_instance.z chains with the existing move from aws_instance.c to aws_instance.d",
+		"aws_instance.f is already the destination of aws_instance.e, not aws_instance.y",
+	}
+	for i, c := range gotConflicts {
+		if i < len(wantStrings) && c.String() != wantStrings[i] {
+			t.Errorf("conflict %d = %q, want %q", i, c.String(), wantStrings[i])
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// GetProviderSchemas obtains the schemas of the providers the module in the
+// given working directory uses, as returned by `terraform providers schema
+// -json`. The working directory must already be initialized.
+func GetProviderSchemas(ctx context.Context, opts ...Option) (*tfjson.ProviderSchemas, error) {
+	var settings settings
+
+	settings.apply(append(defaultOptions(), opts...))
+
+	err := settings.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	tf, err := tfexec.NewTerraform(settings.workdir, settings.terraformBin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Terraform executor: %w", err)
+	}
+
+	schemas, err := tf.ProvidersSchema(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider schemas: %w", err)
+	}
+
+	return schemas, nil
+}
+
+// SetAttributes returns, for each resource type in the given schemas, the
+// attributes Terraform treats as sets. Attributes are given as patterns of
+// flattened keys, where "*" stands for the elements of a list, set or map,
+// like "ingress.*.security_groups".
+func SetAttributes(schemas *tfjson.ProviderSchemas) map[string][]string {
+	sets := make(map[string][]string)
+	if schemas == nil {
+		return sets
+	}
+
+	for _, provider := range schemas.Schemas {
+		if provider == nil {
+			continue
+		}
+		for resourceType, schema := range provider.ResourceSchemas {
+			if schema == nil || schema.Block == nil {
+				continue
+			}
+
+			attributes := blockSets("", schema.Block)
+			if len(attributes) == 0 {
+				continue
+			}
+
+			sort.Strings(attributes)
+			sets[resourceType] = attributes
+		}
+	}
+
+	return sets
+}
+
+func blockSets(prefix string, block *tfjson.SchemaBlock) []string {
+	var sets []string
+
+	for name, attr := range block.Attributes {
+		if attr == nil {
+			continue
+		}
+		if attr.AttributeNestedType != nil {
+			sets = append(sets, nestedTypeSets(prefix+name, attr.AttributeNestedType)...)
+			continue
+		}
+		sets = append(sets, typeSets(prefix+name, attr.AttributeType)...)
+	}
+
+	for name, nested := range block.NestedBlocks {
+		if nested == nil || nested.Block == nil {
+			continue
+		}
+
+		path := prefix + name
+		switch nested.NestingMode {
+		case tfjson.SchemaNestingModeSet:
+			sets = append(sets, path)
+			sets = append(sets, blockSets(path+".*.", nested.Block)...)
+		case tfjson.SchemaNestingModeList, tfjson.SchemaNestingModeMap:
+			sets = append(sets, blockSets(path+".*.", nested.Block)...)
+		default:
+			sets = append(sets, blockSets(path+".", nested.Block)...)
+		}
+	}
+
+	return sets
+}
+
+func nestedTypeSets(path string, nested *tfjson.SchemaNestedAttributeType) []string {
+	var sets []string
+
+	prefix := path + "."
+	switch nested.NestingMode {
+	case tfjson.SchemaNestingModeSet:
+		sets = append(sets, path)
+		prefix = path + ".*."
+	case tfjson.SchemaNestingModeList, tfjson.SchemaNestingModeMap:
+		prefix = path + ".*."
+	}
+
+	for name, attr := range nested.Attributes {
+		if attr == nil {
+			continue
+		}
+		if attr.AttributeNestedType != nil {
+			sets = append(sets, nestedTypeSets(prefix+name, attr.AttributeNestedType)...)
+			continue
+		}
+		sets = append(sets, typeSets(prefix+name, attr.AttributeType)...)
+	}
+
+	return sets
+}
+
+func typeSets(path string, t cty.Type) []string {
+	switch {
+	case t.IsSetType():
+		return append([]string{path}, typeSets(path+".*", t.ElementType())...)
+	case t.IsListType(), t.IsMapType():
+		return typeSets(path+".*", t.ElementType())
+	case t.IsObjectType():
+		var sets []string
+		for name, at := range t.AttributeTypes() {
+			sets = append(sets, typeSets(path+"."+name, at)...)
+		}
+		return sets
+	case t.IsTupleType():
+		var sets []string
+		for i, et := range t.TupleElementTypes() {
+			sets = append(sets, typeSets(path+"."+strconv.Itoa(i), et)...)
+		}
+		return sets
+	default:
+		return nil
+	}
+}
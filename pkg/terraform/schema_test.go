@@ -0,0 +1,104 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestSetAttributes(t *testing.T) {
+	raw := `{
+  "format_version": "1.0",
+  "provider_schemas": {
+    "registry.terraform.io/hashicorp/aws": {
+      "resource_schemas": {
+        "aws_instance": {
+          "version": 1,
+          "block": {
+            "attributes": {
+              "ami": {"type": "string", "optional": true},
+              "vpc_security_group_ids": {"type": ["set", "string"], "optional": true},
+              "tags": {"type": ["map", "string"], "optional": true}
+            },
+            "block_types": {
+              "ebs_block_device": {
+                "nesting_mode": "set",
+                "block": {
+                  "attributes": {
+                    "device_name": {"type": "string", "required": true}
+                  }
+                }
+              },
+              "network_interface": {
+                "nesting_mode": "list",
+                "block": {
+                  "attributes": {
+                    "security_groups": {"type": ["set", "string"], "optional": true}
+                  }
+                }
+              },
+              "timeouts": {
+                "nesting_mode": "single",
+                "block": {
+                  "attributes": {
+                    "create": {"type": "string", "optional": true}
+                  }
+                }
+              }
+            }
+          }
+        },
+        "aws_s3_bucket": {
+          "version": 0,
+          "block": {
+            "attributes": {
+              "bucket": {"type": "string", "optional": true},
+              "rules": {
+                "nested_type": {
+                  "nesting_mode": "set",
+                  "attributes": {
+                    "prefixes": {"type": ["list", ["set", "string"]], "optional": true}
+                  }
+                },
+                "optional": true
+              }
+            }
+          }
+        },
+        "aws_vpc": {
+          "version": 1,
+          "block": {
+            "attributes": {
+              "cidr_block": {"type": "string", "optional": true}
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+	var schemas tfjson.ProviderSchemas
+	if err := json.Unmarshal([]byte(raw), &schemas); err != nil {
+		t.Fatalf("failed to parse schemas: %v", err)
+	}
+
+	want := map[string][]string{
+		"aws_instance": {
+			"ebs_block_device",
+			"network_interface.*.security_groups",
+			"vpc_security_group_ids",
+		},
+		"aws_s3_bucket": {
+			"rules",
+			"rules.*.prefixes.*",
+		},
+	}
+
+	got := SetAttributes(&schemas)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
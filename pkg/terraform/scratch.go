@@ -0,0 +1,226 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// CopyWorkdir copies the given working directory to a new scratch directory,
+// where Terraform commands can run without affecting the original. It returns
+// the path to the copy, and a function that removes everything CopyWorkdir
+// created, which the caller is responsible for calling.
+//
+// The copy is created in the system's temporary directory, at the same path
+// as the original within a new scratch root. Local modules the working
+// directory calls from outside of it are linked at the same path within the
+// root, so that their relative sources still work. Git metadata is not
+// copied. Installed providers are linked rather than copied, since they can be
+// large, so the copy must not be initialized again: terraform init would
+// write to the original's providers.
+func CopyWorkdir(workdir string) (string, func(), error) {
+	absWorkdir, err := filepath.Abs(workdir)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get absolute path of %q: %w", workdir, err)
+	}
+
+	root, err := os.MkdirTemp("", "tfautomv-scratch-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	remove := func() { os.RemoveAll(root) }
+
+	scratch := scratchPath(root, absWorkdir)
+	if err := os.MkdirAll(scratch, 0755); err != nil {
+		remove()
+		return "", nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+
+	err = linkLocalModules(absWorkdir, root)
+	if err != nil {
+		remove()
+		return "", nil, fmt.Errorf("failed to link the local modules %q calls: %w", workdir, err)
+	}
+
+	err = copyDir(absWorkdir, scratch)
+	if err != nil {
+		remove()
+		return "", nil, fmt.Errorf("failed to copy %q to scratch directory: %w", workdir, err)
+	}
+
+	return scratch, remove, nil
+}
+
+// linkLocalModules links the local modules the given working directory calls
+// from outside of it to the same path within the given scratch root. Modules
+// are listed in the manifest terraform init writes, with their directory
+// relative to the working directory.
+func linkLocalModules(workdir, root string) error {
+	raw, err := os.ReadFile(filepath.Join(workdir, ".terraform", "modules", "modules.json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var manifest struct {
+		Modules []struct {
+			Dir string `json:"Dir"`
+		} `json:"Modules"`
+	}
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return fmt.Errorf("failed to parse module manifest: %w", err)
+	}
+
+	var dirs []string
+	for _, m := range manifest.Modules {
+		dir := m.Dir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workdir, dir)
+		}
+		// Modules within the working directory are copied along with it.
+		if !isWithin(dir, workdir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	// Parent directories come first, so that modules within a directory
+	// already linked are skipped.
+	sort.Strings(dirs)
+
+	var linked []string
+	for _, dir := range dirs {
+		if slices.ContainsFunc(linked, func(l string) bool { return isWithin(dir, l) }) {
+			continue
+		}
+		linked = append(linked, dir)
+
+		// A module that contains the working directory cannot be linked as a
+		// whole, since the copy lives within it.
+		if isWithin(workdir, dir) {
+			if err := linkAncestors(workdir, dir, root); err != nil {
+				return err
+			}
+			continue
+		}
+
+		path := scratchPath(root, dir)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.Symlink(dir, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// linkAncestors links the siblings of the given directory, and of each of its
+// ancestors up to the given top directory, to the same path within the given
+// scratch root.
+func linkAncestors(dir, top, root string) error {
+	for child, parent := dir, filepath.Dir(dir); isWithin(parent, top); child, parent = parent, filepath.Dir(parent) {
+		entries, err := os.ReadDir(parent)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			path := filepath.Join(parent, e.Name())
+			if path == child || e.Name() == ".git" {
+				continue
+			}
+			if err := os.Symlink(path, scratchPath(root, path)); err != nil {
+				return err
+			}
+		}
+
+		if parent == top {
+			break
+		}
+	}
+
+	return nil
+}
+
+// isWithin reports whether the given path is the given directory or within it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// scratchPath returns where the given absolute path is within a scratch root.
+func scratchPath(root, path string) string {
+	return filepath.Join(root, strings.TrimPrefix(path, filepath.VolumeName(path)))
+}
+
+func copyDir(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		srcPath := filepath.Join(src, e.Name())
+		dstPath := filepath.Join(dst, e.Name())
+
+		switch {
+		case e.Name() == ".git":
+			continue
+		case e.Name() == "providers" && filepath.Base(src) == ".terraform" && e.IsDir():
+			// Providers can be large, and only terraform init writes to them.
+			err = os.Symlink(srcPath, dstPath)
+		case e.IsDir():
+			err = os.Mkdir(dstPath, 0755)
+			if err == nil {
+				err = copyDir(srcPath, dstPath)
+			}
+		case e.Type()&os.ModeSymlink != 0:
+			var target string
+			target, err = os.Readlink(srcPath)
+			if err == nil {
+				err = os.Symlink(target, dstPath)
+			}
+		case e.Type().IsRegular():
+			err = copyFile(srcPath, dstPath)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,171 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCopyWorkdir(t *testing.T) {
+	parent := t.TempDir()
+	workdir := filepath.Join(parent, "workdir")
+
+	// A local module the working directory calls with a relative path, and a
+	// directory it does not use.
+	for _, dir := range []string{"modules/vpc", "unrelated"} {
+		if err := os.MkdirAll(filepath.Join(parent, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(parent, dir, "main.tf"), []byte(`variable "cidr" {}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := map[string]string{
+		"main.tf":                            `resource "random_pet" "this" {}`,
+		"terraform.tfstate":                  `{}`,
+		"nested/module.tf":                   `variable "foo" {}`,
+		".terraform/terraform.tfstate":       `{"backend": {}}`,
+		".terraform/providers/provider.bin":  "binary",
+		".terraform/modules/modules.json":    `{"Modules": [{"Key": "", "Dir": "."}, {"Key": "vpc", "Dir": "../modules/vpc"}, {"Key": "s3", "Dir": ".terraform/modules/s3"}]}`,
+		".terraform/modules/s3/main.tf":      `variable "bucket" {}`,
+		".terraform.lock.hcl":                `provider "random" {}`,
+		"nested/deeper/still/something.json": `{}`,
+		".git/HEAD":                          "ref: refs/heads/main",
+	}
+
+	for path, content := range files {
+		path = filepath.Join(workdir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scratch, remove, err := CopyWorkdir(workdir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer remove()
+
+	if !strings.HasPrefix(scratch, os.TempDir()) {
+		t.Errorf("scratch directory %q is not in %q", scratch, os.TempDir())
+	}
+
+	// Nothing is created next to the original.
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("got %d entries next to the original, want 3", len(entries))
+	}
+
+	// Git metadata is not copied.
+	if _, err := os.Stat(filepath.Join(scratch, ".git")); !os.IsNotExist(err) {
+		t.Errorf(".git was copied")
+	}
+	delete(files, ".git/HEAD")
+
+	// Relative paths to local modules still work.
+	module, err := os.ReadFile(filepath.Join(scratch, "..", "modules", "vpc", "main.tf"))
+	if err != nil {
+		t.Errorf("failed to read local module from scratch directory: %v", err)
+	} else if string(module) != `variable "cidr" {}` {
+		t.Errorf("local module = %q", module)
+	}
+
+	// Directories no module source references are left out.
+	if _, err := os.Lstat(filepath.Join(scratch, "..", "unrelated")); !os.IsNotExist(err) {
+		t.Errorf("unrelated directory was linked")
+	}
+
+	for path, want := range files {
+		got, err := os.ReadFile(filepath.Join(scratch, path))
+		if err != nil {
+			t.Errorf("failed to read %q from scratch directory: %v", path, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%q = %q, want %q", path, got, want)
+		}
+	}
+
+	// Installed providers are linked, and installed modules copied.
+	for dir, wantLink := range map[string]bool{".terraform/providers": true, ".terraform/modules": false} {
+		info, err := os.Lstat(filepath.Join(scratch, dir))
+		if err != nil {
+			t.Errorf("failed to stat %q: %v", dir, err)
+			continue
+		}
+		if isLink := info.Mode()&os.ModeSymlink != 0; isLink != wantLink {
+			t.Errorf("%q is a symlink: %v, want %v", dir, isLink, wantLink)
+		}
+	}
+
+	// Changes to the copy do not affect the original.
+	if err := os.WriteFile(filepath.Join(scratch, "main.tf"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	original, err := os.ReadFile(filepath.Join(workdir, "main.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(original) != files["main.tf"] {
+		t.Errorf("original main.tf was modified")
+	}
+
+	remove()
+	if _, err := os.Stat(scratch); !os.IsNotExist(err) {
+		t.Errorf("scratch directory %q still exists after removal", scratch)
+	}
+	if _, err := os.Stat(filepath.Join(parent, "modules", "vpc", "main.tf")); err != nil {
+		t.Errorf("removal affected the original: %v", err)
+	}
+}
+
+func TestCopyWorkdirWithinModule(t *testing.T) {
+	// The working directory calls the module it lives in.
+	parent := t.TempDir()
+	workdir := filepath.Join(parent, "envs", "prod")
+
+	files := map[string]string{
+		"main.tf":              `variable "env" {}`,
+		"envs/staging/main.tf": `module "root" { source = "../.." }`,
+		"envs/prod/main.tf":    `module "root" { source = "../.." }`,
+		"envs/prod/.terraform/modules/modules.json": `{"Modules": [{"Key": "", "Dir": "."}, {"Key": "root", "Dir": "../.."}]}`,
+	}
+	for path, content := range files {
+		path = filepath.Join(parent, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scratch, remove, err := CopyWorkdir(workdir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer remove()
+
+	for _, path := range []string{"../../main.tf", "../staging/main.tf", "main.tf"} {
+		if _, err := os.ReadFile(filepath.Join(scratch, path)); err != nil {
+			t.Errorf("failed to read %q from scratch directory: %v", path, err)
+		}
+	}
+
+	// The copy itself is not a link to the original.
+	info, err := os.Lstat(scratch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("scratch directory is a symlink")
+	}
+}
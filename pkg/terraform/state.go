@@ -0,0 +1,67 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// GetState obtains the current state of the module in the given working
+// directory, as returned by `terraform show -json`.
+func GetState(ctx context.Context, opts ...Option) (*tfjson.State, error) {
+	var settings settings
+
+	settings.apply(append(defaultOptions(), opts...))
+
+	err := settings.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	tf, err := tfexec.NewTerraform(settings.workdir, settings.terraformBin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Terraform executor: %w", err)
+	}
+
+	if !settings.skipInit {
+		err := tf.Init(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize Terraform: %w", err)
+		}
+	}
+
+	state, err := tf.Show(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Terraform state: %w", err)
+	}
+
+	return state, nil
+}
+
+// StateAddresses returns the addresses of all resources in the given state,
+// including those in child modules.
+func StateAddresses(state *tfjson.State) []string {
+	if state == nil || state.Values == nil {
+		return nil
+	}
+
+	var addresses []string
+
+	var walk func(m *tfjson.StateModule)
+	walk = func(m *tfjson.StateModule) {
+		if m == nil {
+			return
+		}
+		for _, r := range m.Resources {
+			addresses = append(addresses, r.Address)
+		}
+		for _, child := range m.ChildModules {
+			walk(child)
+		}
+	}
+	walk(state.Values.RootModule)
+
+	return addresses
+}
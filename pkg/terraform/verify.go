@@ -0,0 +1,165 @@
+package terraform
+
+import (
+	"reflect"
+	"sort"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// A VerificationStatus describes what Terraform plans for a moved resource
+// once the move is applied.
+type VerificationStatus string
+
+const (
+	// Terraform plans no changes to the resource.
+	VerifiedNoOp VerificationStatus = "no-op"
+	// Terraform plans to update the resource in place.
+	VerifiedUpdate VerificationStatus = "update"
+	// Terraform still plans to create or delete the resource.
+	VerifiedReplace VerificationStatus = "replace"
+	// The resource does not appear in the plan at all.
+	VerifiedMissing VerificationStatus = "missing"
+)
+
+// A Verification is what Terraform plans for a moved resource once the move
+// is applied.
+type Verification struct {
+	Move   Move
+	Status VerificationStatus
+
+	// The attributes Terraform plans to update in place. Attributes of
+	// resources within a moved module are prefixed with the resource's
+	// address.
+	Attributes []string
+}
+
+// OK reports whether the move removed the need to create and delete the
+// resource.
+func (v Verification) OK() bool {
+	return v.Status == VerifiedNoOp || v.Status == VerifiedUpdate
+}
+
+// VerifyMoves checks, for each move, what the given plan does with the moved
+// resource. The plan must be computed with the moves already applied, for
+// example with moved blocks written to a copy of the working directory.
+//
+// A move of a whole module or resource covers every resource instance within
+// it, and its status is the worst among them.
+func VerifyMoves(plan *tfjson.Plan, moves []Move) []Verification {
+	var verifications []Verification
+	for _, m := range moves {
+		verifications = append(verifications, verifyMove(plan, m))
+	}
+	return verifications
+}
+
+func verifyMove(plan *tfjson.Plan, m Move) Verification {
+	v := Verification{Move: m, Status: VerifiedNoOp}
+	found := false
+
+	worsen := func(status VerificationStatus) {
+		if status == VerifiedReplace || v.Status == VerifiedNoOp {
+			v.Status = status
+		}
+	}
+
+	for _, rc := range plan.ResourceChanges {
+		if rc.Mode == tfjson.DataResourceMode || rc.Change == nil {
+			continue
+		}
+
+		actions := rc.Change.Actions
+
+		switch {
+		case withinAddress(rc.Address, m.ToAddress):
+			found = true
+
+			switch {
+			case actions.Create(), actions.Delete(), actions.Replace():
+				worsen(VerifiedReplace)
+			case actions.Update():
+				worsen(VerifiedUpdate)
+				for _, attr := range changedAttributes(rc.Change) {
+					if rc.Address != m.ToAddress {
+						attr = rc.Address + "." + attr
+					}
+					v.Attributes = append(v.Attributes, attr)
+				}
+			}
+
+		case withinAddress(rc.Address, m.FromAddress):
+			if actions.Delete() || actions.Replace() {
+				worsen(VerifiedReplace)
+			}
+		}
+	}
+
+	if !found {
+		v.Status = VerifiedMissing
+	}
+
+	if v.Status != VerifiedUpdate {
+		v.Attributes = nil
+	}
+
+	return v
+}
+
+// withinAddress reports whether the given resource address is the given
+// address, or is within it.
+func withinAddress(resource, address string) bool {
+	return containsAddress([]string{resource}, address)
+}
+
+// changedAttributes returns the top-level attributes whose values differ
+// before and after the change, including those only known after apply.
+func changedAttributes(change *tfjson.Change) []string {
+	before, _ := change.Before.(map[string]any)
+	after, _ := change.After.(map[string]any)
+	unknown, _ := change.AfterUnknown.(map[string]any)
+
+	keys := make(map[string]bool)
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+	for k := range unknown {
+		keys[k] = true
+	}
+
+	var changed []string
+	for k := range keys {
+		if isUnknown(unknown[k]) || !reflect.DeepEqual(before[k], after[k]) {
+			changed = append(changed, k)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
+
+// isUnknown reports whether a value from a change's after_unknown field marks
+// something as only known after apply.
+func isUnknown(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case []any:
+		for _, e := range v {
+			if isUnknown(e) {
+				return true
+			}
+		}
+	case map[string]any:
+		for _, e := range v {
+			if isUnknown(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
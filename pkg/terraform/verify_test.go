@@ -0,0 +1,85 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestVerifyMoves(t *testing.T) {
+	change := func(address string, actions tfjson.Actions, before, after map[string]any) *tfjson.ResourceChange {
+		return &tfjson.ResourceChange{
+			Address: address,
+			Mode:    tfjson.ManagedResourceMode,
+			Change: &tfjson.Change{
+				Actions: actions,
+				Before:  before,
+				After:   after,
+			},
+		}
+	}
+
+	noop := tfjson.Actions{tfjson.ActionNoop}
+	update := tfjson.Actions{tfjson.ActionUpdate}
+	create := tfjson.Actions{tfjson.ActionCreate}
+	remove := tfjson.Actions{tfjson.ActionDelete}
+	replace := tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate}
+
+	unknownTags := change("aws_instance.unknown", update, map[string]any{"tags": nil}, map[string]any{"tags": nil})
+	unknownTags.Change.AfterUnknown = map[string]any{"tags": true}
+
+	plan := &tfjson.Plan{
+		ResourceChanges: []*tfjson.ResourceChange{
+			change("aws_instance.same", noop, nil, nil),
+			change("aws_instance.updated", update,
+				map[string]any{"name": "a", "size": "small", "zone": "x"},
+				map[string]any{"name": "b", "size": "large", "zone": "x"},
+			),
+			change("aws_instance.replaced", replace, nil, nil),
+			change("aws_instance.created", create, nil, nil),
+			change("aws_instance.leftover", remove, nil, nil),
+			change("module.new.aws_instance.a", noop, nil, nil),
+			change("module.new.aws_instance.b", update, map[string]any{"name": "a"}, map[string]any{"name": "b"}),
+			unknownTags,
+		},
+	}
+
+	move := func(from, to string) Move {
+		return Move{FromWorkdir: ".", ToWorkdir: ".", FromAddress: from, ToAddress: to}
+	}
+
+	moves := []Move{
+		move("aws_instance.old_same", "aws_instance.same"),
+		move("aws_instance.old_updated", "aws_instance.updated"),
+		move("aws_instance.old_replaced", "aws_instance.replaced"),
+		move("aws_instance.old_created", "aws_instance.created"),
+		move("aws_instance.leftover", "aws_instance.same"),
+		move("aws_instance.old_missing", "aws_instance.missing"),
+		move("module.old", "module.new"),
+		move("aws_instance.old_unknown", "aws_instance.unknown"),
+	}
+
+	want := []Verification{
+		{Move: moves[0], Status: VerifiedNoOp},
+		{Move: moves[1], Status: VerifiedUpdate, Attributes: []string{"name", "size"}},
+		{Move: moves[2], Status: VerifiedReplace},
+		{Move: moves[3], Status: VerifiedReplace},
+		{Move: moves[4], Status: VerifiedReplace},
+		{Move: moves[5], Status: VerifiedMissing},
+		{Move: moves[6], Status: VerifiedUpdate, Attributes: []string{"module.new.aws_instance.b.name"}},
+		{Move: moves[7], Status: VerifiedUpdate, Attributes: []string{"tags"}},
+	}
+
+	got := VerifyMoves(plan, moves)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	for i, v := range got {
+		wantOK := v.Status == VerifiedNoOp || v.Status == VerifiedUpdate
+		if v.OK() != wantOK {
+			t.Errorf("verification %d: OK() = %v, want %v", i, v.OK(), wantOK)
+		}
+	}
+}
@@ -1,33 +1,53 @@
 package terraform
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"os/exec"
+	"regexp"
 
 	"github.com/hashicorp/go-version"
-	"github.com/hashicorp/terraform-exec/tfexec"
 )
 
-// GetVersion obtains the version of the configured Terraform binary.
-func GetVersion(ctx context.Context, opts ...Option) (*version.Version, error) {
+// GetVersion obtains the version of the configured binary, and whether it is
+// OpenTofu rather than Terraform. Both are read from what its version command
+// prints. The binary's name is not enough, since wrappers and symlinks can
+// have any name.
+func GetVersion(ctx context.Context, opts ...Option) (v *version.Version, openTofu bool, err error) {
 	var settings settings
 
 	settings.apply(append(defaultOptions(), opts...))
 
-	err := settings.validate()
+	err = settings.validate()
 	if err != nil {
-		return nil, fmt.Errorf("invalid options: %w", err)
+		return nil, false, fmt.Errorf("invalid options: %w", err)
 	}
 
-	tf, err := tfexec.NewTerraform(settings.workdir, settings.terraformBin)
+	cmd := exec.CommandContext(ctx, settings.terraformBin, "version")
+	cmd.Dir = settings.workdir
+	out, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Terraform executor: %w", err)
+		return nil, false, fmt.Errorf("\"%s version\" failed: %w", settings.terraformBin, err)
 	}
 
-	version, _, err := tf.Version(ctx, false)
+	return parseVersion(out)
+}
+
+// versionPattern matches the first line the version command of Terraform or
+// OpenTofu prints, like "Terraform v1.9.5" or "OpenTofu v1.8.1".
+var versionPattern = regexp.MustCompile(`^(Terraform|OpenTofu) v(\S+)`)
+
+func parseVersion(out []byte) (*version.Version, bool, error) {
+	match := versionPattern.FindSubmatch(bytes.TrimSpace(out))
+	if match == nil {
+		return nil, false, fmt.Errorf("unexpected output of version command: %q", out)
+	}
+
+	v, err := version.NewVersion(string(match[2]))
 	if err != nil {
-		return nil, fmt.Errorf("\"terraform version\" failed: %w", err)
+		return nil, false, fmt.Errorf("failed to parse version: %w", err)
 	}
 
-	return version, nil
+	return v, string(match[1]) == "OpenTofu", nil
 }
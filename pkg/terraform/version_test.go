@@ -0,0 +1,49 @@
+package terraform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetVersion(t *testing.T) {
+	tests := map[string]struct {
+		output       string
+		wantVersion  string
+		wantOpenTofu bool
+	}{
+		"terraform": {
+			output:       "Terraform v1.9.5\non linux_amd64",
+			wantVersion:  "1.9.5",
+			wantOpenTofu: false,
+		},
+		"opentofu": {
+			output:       "OpenTofu v1.8.1\non linux_amd64",
+			wantVersion:  "1.8.1",
+			wantOpenTofu: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			// The binary's name gives nothing away, only its output does.
+			bin := filepath.Join(t.TempDir(), "tf")
+			script := "#!/bin/sh\nprintf '" + tt.output + "\\n'\n"
+			if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			v, openTofu, err := GetVersion(context.Background(), WithTerraformBin(bin))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v.String() != tt.wantVersion {
+				t.Errorf("GetVersion() version = %v, want %v", v, tt.wantVersion)
+			}
+			if openTofu != tt.wantOpenTofu {
+				t.Errorf("GetVersion() openTofu = %v, want %v", openTofu, tt.wantOpenTofu)
+			}
+		})
+	}
+}
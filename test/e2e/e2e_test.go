@@ -137,9 +137,10 @@ func TestE2E_DependencyAnalysis(t *testing.T) {
 	// random_pet resources.
 	//
 	// Writing a moved block for each random_integer resource would lead to an
-	// empty plan. However, tfautomv does not find these moves because the
-	// random_integer resources have identical attiributes.
-	t.Skip("tfautomv cannot yet solve this case")
+	// empty plan. The random_integer resources have identical attributes, so
+	// comparing them isn't enough. However, the random_pet resources keep
+	// their addresses and reference a different random_integer resource each,
+	// which tells tfautomv which random_integer resource is which.
 
 	workdir := t.TempDir()
 	codePath := filepath.Join(workdir, "main.tf")
@@ -190,6 +191,46 @@ resource "random_pet" "second" {
 	assert.Equal(t, 0, changeCount)
 }
 
+func TestE2E_Iterate(t *testing.T) {
+	tfVersion := terraformVersion(t)
+	if tfVersion.LessThan(version.Must(version.NewVersion("1.1"))) {
+		t.Skip("tfautomv requires Terraform 1.1 or later to run this test")
+	}
+
+	workdir := t.TempDir()
+	codePath := filepath.Join(workdir, "main.tf")
+
+	originalCode := `
+resource "random_integer" "original" {
+	min = 1
+	max = 5
+}
+resource "random_pet" "original" {
+	length = random_integer.original.result
+	prefix = "pet-${random_integer.original.result}"
+}`
+
+	refactoredCode := `
+resource "random_integer" "refactored" {
+	min = 1
+	max = 5
+}
+resource "random_pet" "refactored" {
+	length = random_integer.refactored.result
+	prefix = "pet-${random_integer.refactored.result}"
+}`
+
+	writeCode(t, codePath, originalCode)
+	terraformInitAndApply(t, workdir)
+	writeCode(t, codePath, refactoredCode)
+
+	commands := runTfautomv(t, workdir, []string{"--iterate", "--output=blocks"})
+	assert.Empty(t, commands)
+
+	changeCount := countPlannedChanges(terraformPlan(t, workdir))
+	assert.Equal(t, 0, changeCount)
+}
+
 func TestE2E_MultipleTypes(t *testing.T) {
 	workdir := t.TempDir()
 	codePath := filepath.Join(workdir, "main.tf")
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/busser/tfautomv/pkg/engine"
+	"github.com/busser/tfautomv/pkg/pretty"
+	"github.com/busser/tfautomv/pkg/terraform"
+)
+
+// verifyMoves writes the given moves as moved blocks in scratch copies of each
+// working directory, plans again without refreshing, and reports what
+// Terraform plans for each moved resource. It returns an error if any move
+// fails to remove the need to create and delete a resource.
+func verifyMoves(ctx context.Context, workdirs []string, moves []engine.Move, options []terraform.Option) error {
+	sameWorkdir, differentWorkdir := categorizeMoves(engineMovesToTerraformMoves(moves))
+	independent, chained := terraform.SplitChains(sameWorkdir)
+
+	// Only moved blocks can be written to a scratch copy. Other moves are
+	// performed with state mv commands, which would modify the real state.
+	if skipped := len(differentWorkdir) + len(chained); skipped > 0 {
+		os.Stderr.WriteString(pretty.Colorf("[yellow][bold]note:[reset] %s cannot be verified, since they are not written as moved blocks", pretty.StyledNumMoves(skipped)) + "\n")
+	}
+
+	if len(independent) == 0 {
+		return nil
+	}
+
+	scratchDirs, removeScratch, err := scratchWorkdirs(workdirs, moves)
+	defer removeScratch()
+	if err != nil {
+		return err
+	}
+
+	var verifications []terraform.Verification
+	for i, workdir := range workdirs {
+		var workdirMoves []terraform.Move
+		for _, m := range independent {
+			if m.FromWorkdir == workdir {
+				workdirMoves = append(workdirMoves, m)
+			}
+		}
+		if len(workdirMoves) == 0 {
+			continue
+		}
+
+		os.Stderr.WriteString(pretty.Colorf("verifying %s in %s...", pretty.StyledNumMoves(len(workdirMoves)), (*pretty.Summarizer).StyledModule(nil, workdir)) + "\n")
+
+		// The moves do not depend on remote objects, so refreshing would only
+		// slow verification down. The scratch copy shares the original's
+		// providers, which terraform init would write to.
+		workdirOptions := append(
+			append(append([]terraform.Option{terraform.WithWorkdir(scratchDirs[i])}, options...), workdirOptions(flag.CommandLine, workdir)...),
+			terraform.WithSkipRefresh(true),
+			terraform.WithSkipInit(true),
+		)
+
+		plan, err := terraform.GetPlan(ctx, workdirOptions...)
+		if err != nil {
+			return fmt.Errorf("failed to get plan for workdir %q with moves applied: %w", workdir, err)
+		}
+
+		verifications = append(verifications, terraform.VerifyMoves(plan, workdirMoves)...)
+	}
+
+	os.Stderr.WriteString("\n" + pretty.Verification(verifications) + "\n\n")
+
+	failed := 0
+	for _, v := range verifications {
+		if !v.OK() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d moves failed verification", failed, len(verifications))
+	}
+
+	return nil
+}